@@ -0,0 +1,268 @@
+package pdfgopher
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// stampArtifactTag opens the marked-content sequence pdfcpu wraps around every stamp.
+// Screen readers skip artifacts, so tagged stamps are turned into figures with alternate text.
+const stampArtifactTag = "/Artifact <</Subtype /Watermark /Type /Pagination >>BDC"
+
+var (
+	// objHeaderPattern matches the header of an indirect object.
+	objHeaderPattern = regexp.MustCompile(`(?:^|\s)(\d+)\s+(\d+)\s+obj\b`)
+	// startXRefPattern matches the offset of the last cross-reference section.
+	startXRefPattern = regexp.MustCompile(`startxref\s+(\d+)`)
+	// trailerRefPattern matches an indirect reference entry of a trailer dictionary.
+	trailerRefPattern = regexp.MustCompile(`/(Root|Info)\s*(\d+\s+\d+\s+R)`)
+	// trailerIDPattern matches the file identifier entry of a trailer dictionary.
+	trailerIDPattern = regexp.MustCompile(`/ID\s*(\[[^\]]*\])`)
+)
+
+// pdfStream represents an uncompressed view of a flate encoded stream object of a PDF file.
+type pdfStream struct {
+	Number     int
+	Generation int
+	Content    []byte
+}
+
+// contentDigests returns the digests of the inflated streams of the PDF file, used to recognize
+// the content streams a stamp adds or changes.
+func contentDigests(filePath string) (map[[sha256.Size]byte]bool, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	digests := make(map[[sha256.Size]byte]bool)
+	for _, stream := range flateStreams(data) {
+		digests[sha256.Sum256(stream.Content)] = true
+	}
+
+	return digests, nil
+}
+
+// addStampAltText marks the stamp last added to every content stream that isn't listed in before
+// with alternate text, so screen readers describe it. The PDF file is updated incrementally.
+// There is no structure tree, the text is attached to the marked-content sequence of the stamp.
+func addStampAltText(filePath string, altText string, before map[[sha256.Size]byte]bool) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	tag := fmt.Sprintf("/Figure <</Alt %s>>BDC", pdfTextString(altText))
+
+	var updated []pdfStream
+	for _, stream := range flateStreams(data) {
+		if before[sha256.Sum256(stream.Content)] {
+			continue
+		}
+
+		i := bytes.LastIndex(stream.Content, []byte(stampArtifactTag))
+		if i < 0 {
+			continue
+		}
+
+		content := append(append(append([]byte{}, stream.Content[:i]...), tag...), stream.Content[i+len(stampArtifactTag):]...)
+		updated = append(updated, pdfStream{Number: stream.Number, Generation: stream.Generation, Content: content})
+	}
+
+	if len(updated) == 0 {
+		return fmt.Errorf("no stamp found to add alt text to: %s", filePath)
+	}
+
+	update, err := incrementalUpdate(data, updated)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(update)
+	return err
+}
+
+// flateStreams returns the stream objects of the PDF data that are only flate encoded, inflated.
+// Streams in other encodings, such as images, are skipped.
+func flateStreams(data []byte) []pdfStream {
+	headers := objHeaderPattern.FindAllSubmatchIndex(data, -1)
+
+	var streams []pdfStream
+	for i, header := range headers {
+		end := len(data)
+		if i+1 < len(headers) {
+			end = headers[i+1][0]
+		}
+		object := data[header[1]:end]
+
+		start := bytes.Index(object, []byte("stream"))
+		stop := bytes.Index(object, []byte("endstream"))
+		if start < 0 || stop < start {
+			continue
+		}
+
+		dict := object[:start]
+		if !bytes.Contains(dict, []byte("/FlateDecode")) || bytes.Contains(dict, []byte("/DecodeParms")) || bytes.Contains(dict, []byte("/Type/ObjStm")) {
+			continue
+		}
+
+		raw := bytes.TrimPrefix(object[start+len("stream"):stop], []byte("\r"))
+		raw = bytes.TrimPrefix(raw, []byte("\n"))
+
+		reader, err := zlib.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			continue
+		}
+
+		content, err := io.ReadAll(reader)
+		if err != nil && len(content) == 0 {
+			continue
+		}
+
+		number, _ := strconv.Atoi(string(data[header[2]:header[3]]))
+		generation, _ := strconv.Atoi(string(data[header[4]:header[5]]))
+		streams = append(streams, pdfStream{Number: number, Generation: generation, Content: content})
+	}
+
+	return streams
+}
+
+// incrementalUpdate returns an update section to append to the PDF data that replaces the given
+// stream objects. The cross-reference section matches the one of the original file, a table or a stream.
+func incrementalUpdate(data []byte, streams []pdfStream) ([]byte, error) {
+	matches := startXRefPattern.FindAllSubmatch(data, -1)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no cross-reference section found")
+	}
+
+	prev, err := strconv.Atoi(string(matches[len(matches)-1][1]))
+	if err != nil || prev >= len(data) {
+		return nil, fmt.Errorf("invalid cross-reference offset: %s", matches[len(matches)-1][1])
+	}
+
+	trailer := data[prev:]
+	xrefTable := bytes.HasPrefix(trailer, []byte("xref"))
+	if end := bytes.Index(trailer, []byte("startxref")); end >= 0 {
+		trailer = trailer[:end]
+	}
+
+	size := dictInt(string(trailer), "Size", 0)
+	if size == 0 {
+		return nil, fmt.Errorf("invalid trailer size")
+	}
+
+	var entries []string
+	for _, match := range trailerRefPattern.FindAllSubmatch(trailer, -1) {
+		entries = append(entries, fmt.Sprintf("/%s %s", match[1], match[2]))
+	}
+	if match := trailerIDPattern.FindSubmatch(trailer); match != nil {
+		entries = append(entries, "/ID "+string(match[1]))
+	}
+	entries = append(entries, fmt.Sprintf("/Prev %d", prev))
+
+	var update bytes.Buffer
+	offsets := make(map[int]int)
+	generations := make(map[int]int)
+
+	update.WriteString("\n")
+	for _, stream := range streams {
+		var compressed bytes.Buffer
+		writer := zlib.NewWriter(&compressed)
+		writer.Write(stream.Content)
+		writer.Close()
+
+		offsets[stream.Number] = len(data) + update.Len()
+		generations[stream.Number] = stream.Generation
+		fmt.Fprintf(&update, "%d %d obj\n<</Filter/FlateDecode/Length %d>>\nstream\n", stream.Number, stream.Generation, compressed.Len())
+		update.Write(compressed.Bytes())
+		update.WriteString("\nendstream\nendobj\n")
+	}
+
+	xrefOffset := len(data) + update.Len()
+
+	if xrefTable {
+		numbers := sortedKeys(offsets)
+
+		update.WriteString("xref\n")
+		for _, number := range numbers {
+			fmt.Fprintf(&update, "%d 1\n%010d %05d n\r\n", number, offsets[number], generations[number])
+		}
+		fmt.Fprintf(&update, "trailer\n<</Size %d %s>>\n", size, strings.Join(entries, " "))
+	} else {
+		// The cross-reference stream lists itself as a new object
+		offsets[size] = xrefOffset
+		numbers := sortedKeys(offsets)
+
+		var index []string
+		var rows bytes.Buffer
+		for _, number := range numbers {
+			index = append(index, fmt.Sprintf("%d 1", number))
+
+			row := make([]byte, 7)
+			row[0] = 1
+			binary.BigEndian.PutUint32(row[1:5], uint32(offsets[number]))
+			binary.BigEndian.PutUint16(row[5:7], uint16(generations[number]))
+			rows.Write(row)
+		}
+
+		fmt.Fprintf(&update, "%d 0 obj\n<</Type/XRef/Size %d/Index[%s]/W[1 4 2]/Length %d %s>>\nstream\n", size, size+1, strings.Join(index, " "), rows.Len(), strings.Join(entries, " "))
+		update.Write(rows.Bytes())
+		update.WriteString("\nendstream\nendobj\n")
+	}
+
+	fmt.Fprintf(&update, "startxref\n%d\n%%%%EOF\n", xrefOffset)
+
+	return update.Bytes(), nil
+}
+
+// sortedKeys returns the keys of m in ascending order.
+func sortedKeys(m map[int]int) []int {
+	keys := make([]int, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+
+	return keys
+}
+
+// pdfTextString encodes s as a PDF text string, a literal string for printable ASCII
+// and a UTF-16BE hex string otherwise.
+func pdfTextString(s string) string {
+	ascii := true
+	for _, r := range s {
+		if r < 0x20 || r > 0x7e {
+			ascii = false
+			break
+		}
+	}
+
+	if ascii {
+		replacer := strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`)
+		return "(" + replacer.Replace(s) + ")"
+	}
+
+	encoded := []byte{0xfe, 0xff}
+	for _, unit := range utf16.Encode([]rune(s)) {
+		encoded = append(encoded, byte(unit>>8), byte(unit))
+	}
+
+	return "<" + strings.ToUpper(hex.EncodeToString(encoded)) + ">"
+}
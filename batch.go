@@ -0,0 +1,107 @@
+package pdfgopher
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// BatchError reports the files of a batch that failed to process, by file path.
+type BatchError struct {
+	Errors map[string]error
+}
+
+// Error returns the failed files with their errors, sorted by file path.
+func (e *BatchError) Error() string {
+	paths := make([]string, 0, len(e.Errors))
+	for path := range e.Errors {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	messages := make([]string, len(paths))
+	for i, path := range paths {
+		messages[i] = fmt.Sprintf("%s: %s", path, e.Errors[path].Error())
+	}
+
+	return fmt.Sprintf("%d of the batch files failed: %s", len(paths), strings.Join(messages, "; "))
+}
+
+// Unwrap returns the errors of the failed files, for errors.Is and errors.As.
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+
+	return errs
+}
+
+// WriteManifest writes a JSON array summarizing the processors to w, one object per file with its
+// input and output paths, whether the output was encrypted, its page count and the error if any.
+func WriteManifest(w io.Writer, processors []*PDFProcessor) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(processors)
+}
+
+// WithConcurrency returns an Option function that sets how many files ProcessBatch processes at
+// the same time. Every file runs several pdfcpu processes, the default is the number of CPUs.
+func WithConcurrency(n int) Option {
+	return func(p *PDFProcessor) {
+		p.concurrency = n
+	}
+}
+
+// ProcessBatch processes every file in paths with the given options and returns the processors
+// in the order of paths. A failing file doesn't stop the batch, the processor of every file is
+// returned and a *BatchError lists the files that failed.
+// Close every processor to remove the files it created.
+func ProcessBatch(paths []string, options ...Option) ([]*PDFProcessor, error) {
+	concurrency := NewPDFGopher("", options...).concurrency
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+
+	results := make([]*PDFProcessor, len(paths))
+	errs := make([]error, len(paths))
+
+	// Process the files with a bounded number of workers
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for worker := 0; worker < concurrency && worker < len(paths); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range jobs {
+				results[i] = NewPDFGopher(paths[i], options...)
+				errs[i] = results[i].ProcessFile()
+			}
+		}()
+	}
+
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	batchErr := &BatchError{Errors: make(map[string]error)}
+	for i, err := range errs {
+		if err != nil {
+			batchErr.Errors[paths[i]] = err
+		}
+	}
+
+	if len(batchErr.Errors) > 0 {
+		return results, batchErr
+	}
+
+	return results, nil
+}
@@ -0,0 +1,87 @@
+package pdfgopher_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	. "github.com/RamdhaniMichan/PDFGopher"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessBatch(t *testing.T) {
+	dir := t.TempDir()
+
+	var paths []string
+	for _, name := range []string{"a.pdf", "b.pdf", "c.pdf"} {
+		path := filepath.Join(dir, name)
+		copyInto(t, "./sample_pdf/process-tree-736885__480.pdf", path)
+		paths = append(paths, path)
+	}
+	missing := filepath.Join(dir, "missing.pdf")
+	paths = append(paths[:1], append([]string{missing}, paths[1:]...)...)
+
+	results, err := ProcessBatch(paths,
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+		WithConcurrency(2),
+	)
+
+	// The missing file doesn't stop the other files
+	var batchErr *BatchError
+	if assert.True(t, errors.As(err, &batchErr)) {
+		assert.Len(t, batchErr.Errors, 1)
+		assert.Contains(t, batchErr.Errors, missing)
+		assert.ErrorContains(t, err, "1 of the batch files failed: "+missing+": ")
+	}
+
+	if assert.Len(t, results, 4) {
+		for i, result := range results {
+			assert.Equal(t, paths[i], result.FilePath)
+
+			if paths[i] == missing {
+				assert.Empty(t, result.OutputPath)
+				continue
+			}
+
+			assert.FileExists(t, result.OutputPath)
+			assert.NotEmpty(t, result.Base64Output)
+		}
+	}
+
+	results, err = ProcessBatch(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestWriteManifest(t *testing.T) {
+	dir := t.TempDir()
+
+	protected := filepath.Join(dir, "protected.pdf")
+	copyInto(t, "./sample_pdf/soal_no_3_protected_protected.pdf", protected)
+	missing := filepath.Join(dir, "missing.pdf")
+
+	results, _ := ProcessBatch([]string{protected, missing},
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png", PasswordPDF: "12345"}),
+	)
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteManifest(&buf, results))
+	assert.NotContains(t, buf.String(), "12345")
+
+	var manifest []map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &manifest))
+	if assert.Len(t, manifest, 2) {
+		assert.Equal(t, protected, manifest[0]["input"])
+		assert.Equal(t, results[0].OutputPath, manifest[0]["output"])
+		assert.Equal(t, true, manifest[0]["encrypted"])
+		assert.Equal(t, float64(results[0].PageCount), manifest[0]["pageCount"])
+		assert.NotContains(t, manifest[0], "error")
+		assert.Positive(t, results[0].PageCount)
+
+		assert.Equal(t, map[string]interface{}{"input": missing, "encrypted": false, "error": results[1].ProcessError}, manifest[1])
+		assert.NotEmpty(t, results[1].ProcessError)
+	}
+}
@@ -0,0 +1,129 @@
+package pdfgopher
+
+import (
+	"context"
+	"fmt"
+)
+
+// fixedStampScale is the relative pdfcpu scale used when neither a width ratio nor a scale is set.
+const fixedStampScale = 0.1
+
+// anchorFactors maps the stamp positions to the horizontal and vertical fraction
+// of the free page space placed left of and below the stamp.
+var anchorFactors = map[StampPosition][2]float64{
+	TopLeft: {0, 1}, TopCenter: {0.5, 1}, TopRight: {1, 1},
+	Left: {0, 0.5}, Center: {0.5, 0.5}, Right: {1, 0.5},
+	BottomLeft: {0, 0}, BottomCenter: {0.5, 0}, BottomRight: {1, 0},
+}
+
+// StampBoundsError reports a stamp whose bounding box extends beyond the media box of a page.
+type StampBoundsError struct {
+	Page     int
+	Stamp    Rect
+	MediaBox Rect
+}
+
+// Error implements the error interface.
+func (e *StampBoundsError) Error() string {
+	return fmt.Sprintf("stamp on page %d is out of bounds: stamp (%.2f, %.2f, %.2f, %.2f), media box (%.2f, %.2f, %.2f, %.2f)",
+		e.Page, e.Stamp.LLX, e.Stamp.LLY, e.Stamp.URX, e.Stamp.URY,
+		e.MediaBox.LLX, e.MediaBox.LLY, e.MediaBox.URX, e.MediaBox.URY)
+}
+
+// CheckStampBounds computes where the stamp described by spec lands on every page of the PDF file.
+// It returns a *StampBoundsError for the first page where the stamp extends beyond the media box.
+func CheckStampBounds(filePath string, spec StampSpec) error {
+	ctx := context.Background()
+
+	source, err := openStampSource(ctx, spec)
+	if err != nil {
+		return err
+	}
+
+	mediaBoxes, err := pageMediaBoxes(ctx, filePath)
+	if err != nil {
+		return err
+	}
+
+	position := spec.Position
+	if position == "" {
+		position = BottomRight
+	}
+
+	for i, mediaBox := range mediaBoxes {
+		size := pageSize{Width: mediaBox.URX - mediaBox.LLX, Height: mediaBox.URY - mediaBox.LLY}
+		stamp, err := stampRect(size, source.Width, source.Height, position, spec)
+		if err != nil {
+			return err
+		}
+
+		// pdfcpu places the stamp relative to the lower left corner of the media box
+		stamp.LLX += mediaBox.LLX
+		stamp.URX += mediaBox.LLX
+		stamp.LLY += mediaBox.LLY
+		stamp.URY += mediaBox.LLY
+
+		if !rectContains(mediaBox, stamp) {
+			return &StampBoundsError{Page: i + 1, Stamp: stamp, MediaBox: mediaBox}
+		}
+	}
+
+	return nil
+}
+
+// stampRect computes the bounding box of an image or PDF page stamp on a page, mirroring pdfcpu's placement.
+// Without a stamp box or width ratio in spec its relative Scale is used, the offset of spec moves the box.
+// Rotation is not accounted for.
+func stampRect(page pageSize, imageWidth, imageHeight float64, position StampPosition, spec StampSpec) (Rect, error) {
+	err := validateStampPosition(position)
+	if err != nil {
+		return Rect{}, err
+	}
+
+	factors := anchorFactors[position]
+
+	if imageWidth <= 0 || imageHeight <= 0 {
+		return Rect{}, fmt.Errorf("invalid stamp dimensions %gx%g", imageWidth, imageHeight)
+	}
+
+	aspectRatio := imageWidth / imageHeight
+
+	scale := spec.Scale
+	if scale == 0 {
+		scale = fixedStampScale
+	}
+
+	var width, height float64
+	switch {
+	case spec.MaxWidth != 0 || spec.MaxHeight != 0:
+		scale, err := fitScale(imageWidth, imageHeight, spec.MaxWidth, spec.MaxHeight)
+		if err != nil {
+			return Rect{}, err
+		}
+
+		width = scale * imageWidth
+		height = scale * imageHeight
+	case spec.WidthRatio > 0:
+		width = spec.WidthRatio * page.Width
+		height = width / aspectRatio
+	case aspectRatio >= 1:
+		width = scale * page.Width
+		height = width / aspectRatio
+	default:
+		height = scale * page.Height
+		width = height * aspectRatio
+	}
+
+	x := factors[0]*(page.Width-width) + spec.OffsetX
+	y := factors[1]*(page.Height-height) + spec.OffsetY
+
+	return Rect{LLX: x, LLY: y, URX: x + width, URY: y + height}, nil
+}
+
+// rectContains reports whether inner lies within outer, allowing for rounding errors.
+func rectContains(outer Rect, inner Rect) bool {
+	const epsilon = 0.01
+
+	return inner.LLX >= outer.LLX-epsilon && inner.LLY >= outer.LLY-epsilon &&
+		inner.URX <= outer.URX+epsilon && inner.URY <= outer.URY+epsilon
+}
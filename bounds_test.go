@@ -0,0 +1,59 @@
+package pdfgopher_test
+
+import (
+	"os/exec"
+	"testing"
+
+	. "github.com/RamdhaniMichan/PDFGopher"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckStampBounds(t *testing.T) {
+	filePath := "./sample_pdf/process-tree-736885__480.pdf"
+
+	err := CheckStampBounds(filePath, StampSpec{ImagePath: "./sample_image/qr-generate.png", Position: "br"})
+	assert.NoError(t, err)
+
+	// A stamp one and a half times the page width cannot fit
+	err = CheckStampBounds(filePath, StampSpec{ImagePath: "./sample_image/qr-generate.png", Position: "br", WidthRatio: 1.5})
+
+	var boundsErr *StampBoundsError
+	if assert.ErrorAs(t, err, &boundsErr) {
+		assert.Equal(t, 1, boundsErr.Page)
+		assert.Less(t, boundsErr.Stamp.LLX, boundsErr.MediaBox.LLX)
+	}
+
+	// A stamp box keeps the stamp within its bounds regardless of the page size
+	err = CheckStampBounds(filePath, StampSpec{ImagePath: "./sample_image/qr-generate.png", Position: "br", MaxWidth: 50, MaxHeight: 20})
+	assert.NoError(t, err)
+
+	// An offset moves the stamp from its anchor
+	err = CheckStampBounds(filePath, StampSpec{ImagePath: "./sample_image/qr-generate.png", Position: "br", OffsetX: -10, OffsetY: 10})
+	assert.NoError(t, err)
+
+	err = CheckStampBounds(filePath, StampSpec{ImagePath: "./sample_image/qr-generate.png", Position: "br", OffsetX: 10})
+	if assert.ErrorAs(t, err, &boundsErr) {
+		assert.Greater(t, boundsErr.Stamp.URX, boundsErr.MediaBox.URX)
+	}
+}
+
+func TestCheckStampBoundsMediaBoxOrigin(t *testing.T) {
+	// A cropped scan whose media box doesn't start at the origin
+	filePath := copyFile(t, "./sample_pdf/process-tree-736885__480.pdf")
+	err := exec.Command("pdfcpu", "boxes", "add", "--", "media:[100 200 712 992]", filePath).Run()
+	assert.NoError(t, err)
+
+	err = CheckStampBounds(filePath, StampSpec{ImagePath: "./sample_image/qr-generate.png", Position: "bl"})
+	assert.NoError(t, err)
+
+	err = CheckStampBounds(filePath, StampSpec{ImagePath: "./sample_image/qr-generate.png", Position: "bl", WidthRatio: 1.5})
+
+	var boundsErr *StampBoundsError
+	if assert.ErrorAs(t, err, &boundsErr) {
+		assert.Equal(t, Rect{LLX: 100, LLY: 200, URX: 712, URY: 992}, boundsErr.MediaBox)
+		assert.InDelta(t, 100, boundsErr.Stamp.LLX, 0.01)
+		assert.InDelta(t, 200, boundsErr.Stamp.LLY, 0.01)
+		assert.Greater(t, boundsErr.Stamp.URX, boundsErr.MediaBox.URX)
+	}
+}
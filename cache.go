@@ -0,0 +1,97 @@
+package pdfgopher
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// WithCacheDir returns an Option function that caches converted images and documents in dir.
+// Cached conversions are named by a hash of the input content and the conversion options,
+// so processing the same input again reuses the conversion instead of redoing it.
+func WithCacheDir(dir string) Option {
+	return func(p *PDFProcessor) {
+		p.cacheDir = dir
+	}
+}
+
+// convertCached converts the input file to PDF with convert, reusing the cached conversion
+// from the cache directory when available. Without a cache directory convert is always called.
+func (p *PDFProcessor) convertCached(convert func() (string, error)) (string, error) {
+	if p.cacheDir == "" {
+		return convert()
+	}
+
+	key, err := cacheKey(p.FilePath, fmt.Sprintf("%+v", p.imageConversion))
+	if err != nil {
+		return "", err
+	}
+
+	cachedPath := filepath.Join(p.cacheDir, key+".pdf")
+	if _, err := os.Stat(cachedPath); err == nil {
+		// Work on a copy, processing modifies the PDF in place
+		pdfFilePath, err := createProcessedFile(p.FilePath, p.tempDir)
+		if err != nil {
+			return "", err
+		}
+
+		err = copyFile(cachedPath, pdfFilePath)
+		if err != nil {
+			os.Remove(pdfFilePath)
+			return "", err
+		}
+
+		return pdfFilePath, nil
+	}
+
+	pdfFilePath, err := convert()
+	if err != nil {
+		return "", err
+	}
+
+	err = os.MkdirAll(p.cacheDir, 0755)
+	if err != nil {
+		return "", err
+	}
+
+	// Write the entry under a temporary name first, so a failed copy is never reused and
+	// concurrent processors never read a partial entry
+	entry, err := os.CreateTemp(p.cacheDir, key+"-*.tmp")
+	if err != nil {
+		return "", err
+	}
+	entry.Close()
+
+	err = copyFile(pdfFilePath, entry.Name())
+	if err == nil {
+		err = os.Rename(entry.Name(), cachedPath)
+	}
+	if err != nil {
+		os.Remove(entry.Name())
+		return "", err
+	}
+
+	return pdfFilePath, nil
+}
+
+// cacheKey returns a hash of the file content and the conversion options.
+func cacheKey(filePath string, options string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return "", err
+	}
+
+	hash.Write([]byte(options))
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,109 @@
+package pdfgopher
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// dependency is an external binary used by the processing pipeline.
+type dependency struct {
+	Name   string
+	Reason string
+}
+
+// Dependencies used by the processing pipeline.
+var (
+	pdfcpuDependency  = dependency{Name: "pdfcpu", Reason: "required to process PDF files"}
+	sofficeDependency = dependency{Name: "soffice", Reason: "LibreOffice, required to convert documents to PDF"}
+)
+
+// executablePaths holds the executables set with SetExecutablePath, by binary name.
+var executablePaths sync.Map
+
+// SetExecutablePath sets the executable run for the binary name, one of "pdfcpu", "soffice",
+// "pdftoppm", "pdftotext" and "tesseract", e.g. to use a vendored build outside PATH.
+// It applies to every processor and helper, an empty path restores the lookup of name in PATH.
+func SetExecutablePath(name string, path string) {
+	if path == "" {
+		executablePaths.Delete(name)
+		return
+	}
+
+	executablePaths.Store(name, path)
+}
+
+// executable returns the executable to run for the binary name.
+func executable(name string) string {
+	if path, ok := executablePaths.Load(name); ok {
+		return path.(string)
+	}
+
+	return name
+}
+
+// lookPathResults caches the exec.LookPath error of every binary for the PATH it was looked up in.
+var lookPathResults sync.Map
+
+// WithDependencyCheck returns an Option function that checks the binaries needed for the input
+// file are installed before any work is done, so a missing binary fails with a clear error.
+// The lookups are cached for the lifetime of the process.
+func WithDependencyCheck() Option {
+	return func(p *PDFProcessor) {
+		p.checkDependencies = true
+	}
+}
+
+// CheckDependencies checks that pdfcpu and soffice are installed and returns an error naming
+// every missing binary and what it is needed for.
+func CheckDependencies() error {
+	return checkDependencies(false, pdfcpuDependency, sofficeDependency)
+}
+
+// checkDependencies looks up every dependency on PATH, or at the path set with SetExecutablePath,
+// reusing previous lookups when cached is true.
+func checkDependencies(cached bool, dependencies ...dependency) error {
+	var missing []string
+	for _, dep := range dependencies {
+		path := executable(dep.Name)
+
+		var err error
+		if cached {
+			err = cachedLookPath(path)
+		} else {
+			_, err = exec.LookPath(path)
+		}
+
+		if err == nil {
+			continue
+		}
+
+		if path == dep.Name {
+			missing = append(missing, fmt.Sprintf("%s not found in PATH (%s)", dep.Name, dep.Reason))
+		} else {
+			missing = append(missing, fmt.Sprintf("%s not found at %s (%s)", dep.Name, path, dep.Reason))
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("missing dependencies: %s", strings.Join(missing, "; "))
+	}
+
+	return nil
+}
+
+// cachedLookPath returns the exec.LookPath error of name, looked up once for every PATH.
+func cachedLookPath(name string) error {
+	key := name + "\x00" + os.Getenv("PATH")
+	if result, ok := lookPathResults.Load(key); ok {
+		err, _ := result.(error)
+		return err
+	}
+
+	_, err := exec.LookPath(name)
+	lookPathResults.Store(key, err)
+
+	return err
+}
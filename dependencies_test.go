@@ -0,0 +1,76 @@
+package pdfgopher_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	. "github.com/RamdhaniMichan/PDFGopher"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckDependencies(t *testing.T) {
+	binDir := t.TempDir()
+	t.Setenv("PATH", binDir)
+
+	assert.EqualError(t, CheckDependencies(), "missing dependencies: "+
+		"pdfcpu not found in PATH (required to process PDF files); "+
+		"soffice not found in PATH (LibreOffice, required to convert documents to PDF)")
+
+	assert.NoError(t, os.WriteFile(filepath.Join(binDir, "pdfcpu"), []byte("#!/bin/sh\n"), 0755))
+	assert.EqualError(t, CheckDependencies(), "missing dependencies: "+
+		"soffice not found in PATH (LibreOffice, required to convert documents to PDF)")
+
+	assert.NoError(t, os.WriteFile(filepath.Join(binDir, "soffice"), []byte("#!/bin/sh\n"), 0755))
+	assert.NoError(t, CheckDependencies())
+}
+
+func TestProcessFileWithDependencyCheck(t *testing.T) {
+	dir := t.TempDir()
+	documentPath := filepath.Join(dir, "report.docx")
+	assert.NoError(t, os.WriteFile(documentPath, []byte("document"), 0644))
+
+	t.Setenv("PATH", t.TempDir())
+
+	err := NewPDFGopher(documentPath, WithDependencyCheck()).ProcessFile()
+	assert.ErrorContains(t, err, "missing dependencies: pdfcpu not found in PATH")
+	assert.ErrorContains(t, err, "soffice not found in PATH")
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestSetExecutablePath(t *testing.T) {
+	pdfcpuPath, err := exec.LookPath("pdfcpu")
+	if err != nil {
+		t.Skip("pdfcpu is not installed")
+	}
+
+	// A vendored pdfcpu outside PATH that records its calls
+	vendorDir := t.TempDir()
+	callsPath := filepath.Join(vendorDir, "calls")
+	vendored := filepath.Join(vendorDir, "pdfcpu-vendored")
+	script := "#!/bin/sh\necho \"$1\" >> '" + callsPath + "'\nexec '" + pdfcpuPath + "' \"$@\"\n"
+	assert.NoError(t, os.WriteFile(vendored, []byte(script), 0755))
+
+	SetExecutablePath("pdfcpu", vendored)
+	t.Cleanup(func() { SetExecutablePath("pdfcpu", "") })
+	t.Setenv("PATH", t.TempDir())
+
+	count, err := PageCount("./sample_pdf/process-tree-736885__480.pdf")
+	assert.NoError(t, err)
+	assert.Positive(t, count)
+
+	calls, err := os.ReadFile(callsPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "info\n", string(calls))
+
+	assert.EqualError(t, CheckDependencies(), "missing dependencies: "+
+		"soffice not found in PATH (LibreOffice, required to convert documents to PDF)")
+
+	SetExecutablePath("pdfcpu", filepath.Join(vendorDir, "missing"))
+	assert.ErrorContains(t, CheckDependencies(), "pdfcpu not found at "+filepath.Join(vendorDir, "missing")+" (required to process PDF files)")
+}
@@ -0,0 +1,102 @@
+package pdfgopher
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+)
+
+// WithFileFilter returns an Option function that limits ProcessDir to files whose name matches
+// one of the glob patterns, such as "*.pdf". Patterns are matched against the base name with
+// filepath.Match.
+func WithFileFilter(patterns ...string) Option {
+	return func(p *PDFProcessor) {
+		p.fileFilter = patterns
+	}
+}
+
+// DetectFileType returns the type of the file, or an empty FileType when it isn't supported.
+func DetectFileType(filePath string) FileType {
+	return getFileType(filePath)
+}
+
+// ProcessDir processes every supported file below root with the given options and returns the
+// processors in walk order. Unsupported files and files rejected by WithFileFilter are skipped.
+// The files are collected before processing, so output files written during the walk aren't
+// processed again. Processing stops at the first error, the processors handled so far are returned,
+// the failed one is closed. Close every returned processor to remove the files it created.
+func ProcessDir(root string, opts ...Option) ([]*PDFProcessor, error) {
+	filter := NewPDFGopher(root, opts...).fileFilter
+
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() || DetectFileType(path) == "" {
+			return nil
+		}
+
+		matched, err := matchesFilter(entry.Name(), filter)
+		if err != nil {
+			return err
+		}
+
+		if matched {
+			paths = append(paths, path)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*PDFProcessor, 0, len(paths))
+	for _, path := range paths {
+		processor, err := processDirFile(path, opts...)
+		if err != nil {
+			return results, fmt.Errorf("error processing %s: %w", path, err)
+		}
+
+		results = append(results, processor)
+	}
+
+	return results, nil
+}
+
+// processDirFile processes a single file of ProcessDir. A processor that failed isn't returned,
+// so it is closed to remove the files it created.
+func processDirFile(path string, opts ...Option) (*PDFProcessor, error) {
+	processor := NewPDFGopher(path, opts...)
+
+	err := processor.ProcessFile()
+	if err != nil {
+		processor.Close()
+		return nil, err
+	}
+
+	return processor, nil
+}
+
+// matchesFilter reports whether name matches one of the glob patterns, every name matches
+// an empty filter.
+func matchesFilter(name string, patterns []string) (bool, error) {
+	if len(patterns) == 0 {
+		return true, nil
+	}
+
+	for _, pattern := range patterns {
+		matched, err := filepath.Match(pattern, name)
+		if err != nil {
+			return false, fmt.Errorf("invalid file filter %q: %s", pattern, err.Error())
+		}
+
+		if matched {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,104 @@
+package pdfgopher_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/RamdhaniMichan/PDFGopher"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessDir(t *testing.T) {
+	root := t.TempDir()
+	copyInto(t, "./sample_pdf/process-tree-736885__480.pdf", filepath.Join(root, "report.pdf"))
+	copyInto(t, "./sample_image/tree-736885__480.jpg", filepath.Join(root, "scans", "tree.jpg"))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "scans", "notes.txt"), []byte("notes"), 0644))
+
+	options := []Option{
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+		WithoutBase64(),
+	}
+
+	results, err := ProcessDir(root, options...)
+	assert.NoError(t, err)
+
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, filepath.Join(root, "report.pdf"), results[0].FilePath)
+		assert.Equal(t, filepath.Join(root, "scans", "tree.jpg"), results[1].FilePath)
+
+		for _, result := range results {
+			assert.FileExists(t, result.OutputPath)
+		}
+	}
+
+	// Only the original PDF matches, the output of the first run is skipped by the filter
+	results, err = ProcessDir(root, append(options, WithFileFilter("report.*"))...)
+	assert.NoError(t, err)
+
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, filepath.Join(root, "report.pdf"), results[0].FilePath)
+	}
+
+	_, err = ProcessDir(root, WithFileFilter("["))
+	assert.ErrorContains(t, err, `invalid file filter "["`)
+}
+
+func TestProcessDirClosesFailedProcessor(t *testing.T) {
+	root := t.TempDir()
+	copyInto(t, "./sample_image/tree-736885__480.jpg", filepath.Join(root, "tree.jpg"))
+
+	// The image is converted before stamping fails
+	tempDir := t.TempDir()
+	results, err := ProcessDir(root,
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+		WithStamps(StampSpec{Pages: "5"}),
+		WithTempDir(tempDir),
+		WithoutBase64(),
+	)
+	assert.EqualError(t, err, "error processing "+filepath.Join(root, "tree.jpg")+": stamp 1: no pages selected by 5")
+	assert.Empty(t, results)
+
+	entries, err := os.ReadDir(tempDir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestDetectFileType(t *testing.T) {
+	assert.Equal(t, PDF, DetectFileType("report.PDF"))
+	assert.Equal(t, Image, DetectFileType("scan.jpeg"))
+	assert.Equal(t, Document, DetectFileType("letter.docx"))
+	assert.Equal(t, Document, DetectFileType("letter.odt"))
+	assert.Equal(t, Document, DetectFileType("letter.RTF"))
+	assert.Equal(t, Document, DetectFileType("budget.xlsx"))
+	assert.Equal(t, Document, DetectFileType("slides.pptx"))
+	assert.Equal(t, FileType(""), DetectFileType("notes.txt"))
+}
+
+func TestSupportedExtensions(t *testing.T) {
+	extensions := SupportedExtensions()
+
+	assert.Equal(t, []string{".doc", ".docx", ".jpeg", ".jpg", ".odt", ".pdf", ".png", ".pptx", ".rtf", ".tif", ".tiff", ".webp", ".xlsx"}, extensions)
+	for _, extension := range extensions {
+		assert.NotEmpty(t, DetectFileType("upload"+extension))
+	}
+}
+
+// copyInto copies src to dst, creating the parent directories of dst.
+func copyInto(t *testing.T, src string, dst string) {
+	t.Helper()
+
+	data, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(dst, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
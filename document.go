@@ -0,0 +1,203 @@
+package pdfgopher
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// StampSpec describes an image or PDF page stamp applied to a PDF file.
+type StampSpec struct {
+	ImagePath string
+	Position  StampPosition
+	// PDFPath stamps page PDFPage of that PDF file instead of the image at ImagePath, as crisp
+	// vector graphics for logos and letterheads. The first page is stamped when PDFPage is zero.
+	PDFPath string
+	PDFPage int
+	// Pages selects the pages stamped by WithStamps with pdfcpu page selectors,
+	// the StampPages of the processor when empty.
+	Pages string
+	// WidthRatio sizes the stamp relative to each page width, zero keeps the fixed pdfcpu scale.
+	WidthRatio float64
+	// MaxWidth and MaxHeight fit the stamp within a box of that many points, keeping the aspect
+	// ratio of the image, e.g. for logos. Either may be zero to only bound the other dimension.
+	// They take precedence over WidthRatio.
+	MaxWidth  float64
+	MaxHeight float64
+	// Scale is the relative pdfcpu scale used without a stamp box or width ratio, 0.1 when zero.
+	Scale float64
+	// Rotation rotates the stamp by the given degrees.
+	Rotation float64
+	// OffsetX and OffsetY move the stamp from its anchor position by that many points, or units of
+	// a --unit extra stamp flag, to the right and up for positive values, e.g. OffsetX -10 moves
+	// a "br" stamp inwards.
+	OffsetX float64
+	OffsetY float64
+	// Opacity ranges from 0 to 1, zero keeps the stamp fully opaque.
+	Opacity float64
+	// AltText describes the stamp to screen readers, e.g. "Verification QR code".
+	AltText string
+
+	extraFlags []string
+}
+
+// PDFDocument accumulates operations on a single PDF file and applies them in order on Save.
+type PDFDocument struct {
+	filePath   string
+	operations []func(filePath string) error
+	err        error
+}
+
+// Open returns a PDFDocument for the PDF file at filePath.
+func Open(filePath string) *PDFDocument {
+	d := &PDFDocument{filePath: filePath}
+
+	if getFileType(filePath) != PDF {
+		d.err = unsupportedFileTypeError(filePath)
+	}
+
+	return d
+}
+
+// Stamp adds an image or PDF page stamp to every page of the document.
+func (d *PDFDocument) Stamp(spec StampSpec) *PDFDocument {
+	if spec.ImagePath == "" && spec.PDFPath == "" {
+		return d.fail(errors.New("stamp image path is empty"))
+	}
+
+	if spec.Position == "" {
+		spec.Position = BottomRight
+	}
+
+	return d.add(func(filePath string) error {
+		return addImageStamp(context.Background(), filePath, spec, nil)
+	})
+}
+
+// SetMetadata sets the metadata of the document.
+func (d *PDFDocument) SetMetadata(metadata OptionMetadataPDF) *PDFDocument {
+	return d.add(func(filePath string) error {
+		return addedMetadata(context.Background(), filePath, &metadata)
+	})
+}
+
+// Encrypt protects the document with the given password.
+func (d *PDFDocument) Encrypt(password string) *PDFDocument {
+	if password == "" {
+		return d.fail(ErrMissingPassword)
+	}
+
+	return d.add(func(filePath string) error {
+		return encrypted(context.Background(), filePath, password, "")
+	})
+}
+
+// Save applies all accumulated operations to a copy of the document written to output.
+// The original file is left untouched and output is only written once every operation succeeded.
+// Saving to the path of the document itself replaces it instead.
+func (d *PDFDocument) Save(output string) error {
+	if d.err != nil {
+		return d.err
+	}
+
+	err := stageFile(output, func(tempPath string) error {
+		err := copyFile(d.filePath, tempPath)
+		if err != nil {
+			return err
+		}
+
+		for _, operation := range d.operations {
+			err = operation(tempPath)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return d.fail(err).err
+	}
+
+	return nil
+}
+
+// Err returns the first error encountered while building or saving the document.
+func (d *PDFDocument) Err() error {
+	return d.err
+}
+
+// add appends an operation unless an earlier step already failed.
+func (d *PDFDocument) add(operation func(filePath string) error) *PDFDocument {
+	if d.err == nil {
+		d.operations = append(d.operations, operation)
+	}
+
+	return d
+}
+
+// fail records err when it is the first error of the document.
+func (d *PDFDocument) fail(err error) *PDFDocument {
+	if d.err == nil {
+		d.err = err
+	}
+
+	return d
+}
+
+// sameFile reports whether the paths a and b name the same file, such as "./a.pdf" and "a.pdf".
+// Paths of files that don't exist yet are compared as cleaned absolute paths.
+func sameFile(a, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	if errA == nil && errB == nil {
+		return os.SameFile(infoA, infoB)
+	}
+
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+
+	return errA == nil && errB == nil && absA == absB
+}
+
+// stageFile calls write with a temporary file next to output and renames it to output once
+// write succeeded, so output is never left partially written. output may be the file write reads.
+func stageFile(output string, write func(tempPath string) error) error {
+	temp, err := os.CreateTemp(filepath.Dir(output), ".stage-*.pdf")
+	if err != nil {
+		return err
+	}
+	temp.Close()
+	defer os.Remove(temp.Name())
+
+	err = write(temp.Name())
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(temp.Name(), output)
+}
+
+// copyFile copies the file at src to dst.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
@@ -0,0 +1,156 @@
+package pdfgopher_test
+
+import (
+	"encoding/json"
+	"image"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+
+	. "github.com/RamdhaniMichan/PDFGopher"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocumentChain(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "chained.pdf")
+
+	doc := Open("./sample_pdf/process-tree-736885__480.pdf")
+	err := doc.Stamp(StampSpec{ImagePath: "./sample_image/qr-generate.png", Position: "tl"}).
+		SetMetadata(OptionMetadataPDF{Title: "Chained", Author: "Gopher", Subject: "Chain"}).
+		Encrypt("secret").
+		Save(output)
+
+	assert.NoError(t, err)
+	assert.NoError(t, doc.Err())
+
+	// The output can only be read with the password
+	assert.Error(t, exec.Command("pdfcpu", "info", output).Run())
+
+	out, err := exec.Command("pdfcpu", "info", "--json", "--upw", "secret", output).Output()
+	assert.NoError(t, err)
+
+	var info struct {
+		Infos []struct {
+			Title       string `json:"title"`
+			Watermarked bool   `json:"watermarked"`
+			Encrypted   bool   `json:"encrypted"`
+		} `json:"infos"`
+	}
+	assert.NoError(t, json.Unmarshal(out, &info))
+
+	if assert.Len(t, info.Infos, 1) {
+		assert.Equal(t, "Chained", info.Infos[0].Title)
+		assert.True(t, info.Infos[0].Watermarked)
+		assert.True(t, info.Infos[0].Encrypted)
+	}
+}
+
+func TestDocumentChainFirstError(t *testing.T) {
+	doc := Open("./sample_pdf/process-tree-736885__480.pdf").
+		Stamp(StampSpec{}).
+		Encrypt("")
+
+	assert.EqualError(t, doc.Err(), "stamp image path is empty")
+	assert.Error(t, doc.Save(filepath.Join(t.TempDir(), "out.pdf")))
+}
+
+func TestDocumentStampKeepsAspectRatio(t *testing.T) {
+	dir := t.TempDir()
+
+	// A wide 4:1 logo
+	logo := image.NewGray(image.Rect(0, 0, 400, 100))
+	for i := range logo.Pix {
+		logo.Pix[i] = 0x40
+	}
+
+	logoPath := filepath.Join(dir, "logo.png")
+	file, err := os.Create(logoPath)
+	assert.NoError(t, err)
+	assert.NoError(t, png.Encode(file, logo))
+	assert.NoError(t, file.Close())
+
+	filePath := filepath.Join(dir, "blank.pdf")
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	assert.NoError(t, pdf.OutputFileAndClose(filePath))
+
+	// The box is square, the logo must be bound by its width only
+	output := filepath.Join(dir, "logo.pdf")
+	err = Open(filePath).
+		Stamp(StampSpec{ImagePath: logoPath, Position: "br", MaxWidth: 120, MaxHeight: 120}).
+		Save(output)
+	assert.NoError(t, err)
+
+	matrix := regexp.MustCompile(`([\d.]+) 0 0 ([\d.]+) 0 0 cm\s*/Im\d+ Do`)
+
+	streams := pageStreams(t, output)
+	if assert.Len(t, streams, 1) {
+		match := matrix.FindStringSubmatch(streams[0])
+		if assert.NotNil(t, match) {
+			width, _ := strconv.ParseFloat(match[1], 64)
+			height, _ := strconv.ParseFloat(match[2], 64)
+
+			assert.InDelta(t, 120, width, 0.1)
+			assert.InDelta(t, 30, height, 0.1)
+		}
+	}
+}
+
+func TestDocumentStampAltText(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "accessible.pdf")
+
+	err := Open(multiPagePDF(t, 2)).
+		Stamp(StampSpec{ImagePath: "./sample_image/qr-generate.png", Position: "br", AltText: "Verification QR code (scan me)"}).
+		Save(output)
+	assert.NoError(t, err)
+
+	// The incremental update must keep the file valid
+	assert.NoError(t, exec.Command("pdfcpu", "validate", output).Run())
+
+	pages := pageStreams(t, output)
+	if assert.Len(t, pages, 2) {
+		for _, page := range pages {
+			assert.Contains(t, page, `/Figure <</Alt (Verification QR code \(scan me\))>>BDC`)
+			assert.NotContains(t, page, "/Subtype /Watermark")
+		}
+	}
+}
+
+func TestDocumentSaveInPlace(t *testing.T) {
+	filePath := multiPagePDF(t, 2)
+	original, err := os.ReadFile(filePath)
+	assert.NoError(t, err)
+
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	relative, err := filepath.Rel(wd, filePath)
+	assert.NoError(t, err)
+
+	// A failing save through another spelling of the path leaves the document as it was
+	err = Open(relative).
+		Stamp(StampSpec{ImagePath: filepath.Join(t.TempDir(), "missing.png"), Position: "br"}).
+		Save(filePath)
+	assert.Error(t, err)
+
+	data, err := os.ReadFile(filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, original, data)
+
+	// A successful save replaces the document
+	err = Open(relative).
+		SetMetadata(OptionMetadataPDF{Title: "In place"}).
+		Save(filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, readInfo(t, filePath).PageCount)
+
+	// Nothing is left next to it
+	entries, err := os.ReadDir(filepath.Dir(filePath))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
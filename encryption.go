@@ -0,0 +1,265 @@
+package pdfgopher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+// EncInfo describes the encryption of a PDF file.
+type EncInfo struct {
+	Encrypted bool `json:"encrypted"`
+	// Cipher is either "RC4" or "AES".
+	Cipher string `json:"cipher,omitempty"`
+	// KeyLength is the length of the encryption key in bits.
+	KeyLength int `json:"keyLength,omitempty"`
+	// Permissions is the signed permissions bitfield P of the encryption dictionary.
+	Permissions int32 `json:"permissions,omitempty"`
+	// UserPassword reports whether a password is needed to open the file.
+	UserPassword bool `json:"userPassword"`
+	// OwnerPassword reports whether the owner password differs from the user password.
+	OwnerPassword bool `json:"ownerPassword"`
+}
+
+// Permissions represents what a user opening an encrypted PDF file with the user password may do,
+// viewing is always allowed. pdfcpu grants the owner password full access, so the permissions
+// are only enforced with an owner password that differs from the user password.
+type Permissions struct {
+	// Print allows printing, including high quality printing.
+	Print bool
+	// Copy allows copying and extracting text and graphics.
+	Copy bool
+	// Modify allows changing the content and assembling the document.
+	Modify bool
+	// Annotate allows adding and changing annotations and filling form fields.
+	Annotate bool
+}
+
+// WithPermissions returns an Option function that sets the permissions of the encrypted output.
+// It requires an OwnerPasswordPDF different from PasswordPDF, otherwise the permissions
+// wouldn't be enforced. Without this option pdfcpu grants no permissions.
+func WithPermissions(permissions Permissions) Option {
+	return func(p *PDFProcessor) {
+		p.permissions = &permissions
+	}
+}
+
+// WithEncryption returns an Option function that encrypts the output with userPassword whether
+// or not the input was protected. Protected inputs are still opened with PasswordPDF and are
+// encrypted again with userPassword. OwnerPasswordPDF sets the owner password as usual.
+func WithEncryption(userPassword string) Option {
+	return func(p *PDFProcessor) {
+		p.forceEncryption = true
+		p.encryptionPassword = userPassword
+	}
+}
+
+// userPassword returns the user password the output is encrypted with.
+func (p *PDFProcessor) userPassword() string {
+	if p.forceEncryption {
+		return p.encryptionPassword
+	}
+
+	return p.OptionFilePDF.PasswordPDF
+}
+
+// pdfcpuPermissions returns the pdfcpu permission bits of permissions, e.g. "x804" for printing.
+func pdfcpuPermissions(permissions Permissions) string {
+	bits := 0
+	if permissions.Print {
+		bits |= 0x804
+	}
+	if permissions.Modify {
+		bits |= 0x408
+	}
+	if permissions.Copy {
+		bits |= 0x210
+	}
+	if permissions.Annotate {
+		bits |= 0x120
+	}
+
+	return fmt.Sprintf("x%03X", bits)
+}
+
+// validatePermissions checks that the passwords enforce the permissions.
+func validatePermissions(userPassword string, ownerPassword string) error {
+	if ownerPassword == "" || ownerPassword == userPassword {
+		return errors.New("permissions require an owner password different from the user password")
+	}
+
+	return nil
+}
+
+// setPermissions sets the permissions of the encrypted PDF file using pdfcpu-cli.
+func setPermissions(ctx context.Context, filePath string, userPassword string, ownerPassword string, permissions Permissions) error {
+	_, err := runPDFCPU(ctx, "permissions", "set", "--perm", pdfcpuPermissions(permissions), "--upw", userPassword, "--opw", ownerPassword, filePath)
+	return err
+}
+
+var (
+	// encryptRefPattern matches the reference to the encryption dictionary in a trailer.
+	encryptRefPattern = regexp.MustCompile(`/Encrypt\s*(\d+)\s+(\d+)\s+R`)
+	// cryptFilterPattern matches the crypt filter dictionaries of an encryption dictionary.
+	cryptFilterPattern = regexp.MustCompile(`(?s)/CF\s*<<.*?>>\s*>>`)
+)
+
+// EncryptionInfo returns the cipher, key length, permissions and passwords of the PDF file.
+// The cipher details are read from the encryption dictionary, password is the user password
+// and only needed when the file can't be opened without one, ErrWrongPassword is returned when it
+// doesn't match. Without a user password the owner password is reported as set, pdfcpu can't
+// verify an empty owner password.
+func EncryptionInfo(filePath string, password string) (*EncInfo, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	dict, err := encryptDict(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if dict == "" {
+		return &EncInfo{}, nil
+	}
+
+	info, err := parseEncryptDict(dict)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	info.UserPassword, err = hasPDFPassword(ctx, filePath, "relaxed")
+	if err != nil {
+		return nil, err
+	}
+
+	info.OwnerPassword = true
+	if info.UserPassword {
+		_, err = runPDFCPU(ctx, "validate", "--mode", "relaxed", "--upw", password, filePath)
+		if err != nil {
+			if exitCode(err) == 1 {
+				return nil, ErrWrongPassword
+			}
+			return nil, err
+		}
+
+		owner, err := hasOwnerAccess(ctx, filePath, password)
+		if err != nil {
+			return nil, err
+		}
+
+		info.OwnerPassword = !owner
+	}
+
+	return info, nil
+}
+
+// hasOwnerAccess reports whether password is the owner password of the PDF file.
+// pdfcpu only changes permissions with the owner password, which is tried on a temporary copy.
+func hasOwnerAccess(ctx context.Context, filePath string, password string) (bool, error) {
+	dir, err := os.MkdirTemp("", "owner-")
+	if err != nil {
+		return false, err
+	}
+	defer os.RemoveAll(dir)
+
+	copyPath := filepath.Join(dir, filepath.Base(filePath))
+	err = copyFile(filePath, copyPath)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = runPDFCPU(ctx, "permissions", "set", "--perm", "all", "--opw", password, "--upw", password, copyPath)
+	if err != nil {
+		if exitCode(err) == 1 {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// encryptDict returns the body of the encryption dictionary referenced by the last trailer of the
+// PDF data, or an empty string when the file isn't encrypted. The encryption dictionary is never
+// stored in an object stream, so it can be read without decrypting anything.
+func encryptDict(data []byte) (string, error) {
+	refs := encryptRefPattern.FindAllSubmatch(data, -1)
+	if len(refs) == 0 {
+		return "", nil
+	}
+
+	ref := refs[len(refs)-1]
+	objPattern := regexp.MustCompile(fmt.Sprintf(`(?s)(?:^|\s)%s\s+%s\s+obj\s*<<(.*?)>>\s*endobj`, ref[1], ref[2]))
+
+	objs := objPattern.FindAllSubmatch(data, -1)
+	if len(objs) == 0 {
+		return "", fmt.Errorf("encryption dictionary %s %s R not found", ref[1], ref[2])
+	}
+
+	return string(objs[len(objs)-1][1]), nil
+}
+
+// parseEncryptDict reads the cipher, key length and permissions from the body of a standard
+// security handler encryption dictionary.
+func parseEncryptDict(dict string) (*EncInfo, error) {
+	info := &EncInfo{Encrypted: true}
+
+	// The crypt filters carry their own Length entries
+	cryptFilters := cryptFilterPattern.FindString(dict)
+	topLevel := cryptFilterPattern.ReplaceAllString(dict, "")
+
+	version := dictInt(topLevel, "V", 0)
+
+	switch version {
+	case 1:
+		info.Cipher, info.KeyLength = "RC4", 40
+	case 2, 3:
+		info.Cipher, info.KeyLength = "RC4", dictInt(topLevel, "Length", 40)
+	case 4, 5:
+		info.Cipher, info.KeyLength = "AES", 128
+		if version == 5 {
+			info.KeyLength = 256
+		}
+
+		match := regexp.MustCompile(`/CFM\s*/(\w+)`).FindStringSubmatch(cryptFilters)
+		if match != nil && match[1] == "V2" {
+			info.Cipher = "RC4"
+		}
+
+		// Crypt filter lengths are in bytes, some writers use bits
+		if length := dictInt(cryptFilters, "Length", 0); version == 4 && length > 0 {
+			if length <= 32 {
+				length *= 8
+			}
+			info.KeyLength = length
+		}
+	default:
+		return nil, fmt.Errorf("unsupported encryption version: %d", version)
+	}
+
+	info.Permissions = int32(dictInt(topLevel, "P", 0))
+
+	return info, nil
+}
+
+// dictInt returns the integer value of key in the PDF dictionary body, or fallback when it is missing.
+func dictInt(dict string, key string, fallback int) int {
+	match := regexp.MustCompile(`/` + key + `\s+(-?\d+)`).FindStringSubmatch(dict)
+	if match == nil {
+		return fallback
+	}
+
+	value, err := strconv.Atoi(match[1])
+	if err != nil {
+		return fallback
+	}
+
+	return value
+}
@@ -0,0 +1,145 @@
+package pdfgopher_test
+
+import (
+	"os/exec"
+	"testing"
+
+	. "github.com/RamdhaniMichan/PDFGopher"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptionInfo(t *testing.T) {
+	// The sample is protected with the same user and owner password
+	info, err := EncryptionInfo("./sample_pdf/soal_no_3_protected_protected.pdf", "12345")
+	assert.NoError(t, err)
+	assert.Equal(t, &EncInfo{
+		Encrypted:     true,
+		Cipher:        "AES",
+		KeyLength:     256,
+		Permissions:   -3901,
+		UserPassword:  true,
+		OwnerPassword: false,
+	}, info)
+
+	_, err = EncryptionInfo("./sample_pdf/soal_no_3_protected_protected.pdf", "wrong")
+	assert.ErrorIs(t, err, ErrWrongPassword)
+
+	filePath := copyFile(t, "./sample_pdf/process-tree-736885__480.pdf")
+
+	info, err = EncryptionInfo(filePath, "")
+	assert.NoError(t, err)
+	assert.Equal(t, &EncInfo{}, info)
+
+	err = exec.Command("pdfcpu", "encrypt", "--mode", "rc4", "--key", "128", "--perm", "print", "--upw", "user", "--opw", "owner", filePath).Run()
+	assert.NoError(t, err)
+
+	info, err = EncryptionInfo(filePath, "user")
+	assert.NoError(t, err)
+	assert.Equal(t, "RC4", info.Cipher)
+	assert.Equal(t, 128, info.KeyLength)
+	assert.NotZero(t, info.Permissions&(1<<2), "print permission")
+	assert.True(t, info.UserPassword)
+	assert.True(t, info.OwnerPassword)
+}
+
+func TestProcessPDFOwnerPassword(t *testing.T) {
+	// Protected inputs are encrypted again after processing
+	pdfProcess := NewPDFGopher(copyFile(t, "./sample_pdf/soal_no_3_protected_protected.pdf"),
+		WithOptionFilePDF(OptionFilePDF{
+			QRCodePath:       "./sample_image/qr-generate.png",
+			PasswordPDF:      "12345",
+			OwnerPasswordPDF: "owner",
+		}),
+		WithoutBase64(),
+	)
+	assert.NoError(t, pdfProcess.ProcessFile())
+
+	info, err := EncryptionInfo(pdfProcess.OutputPath, "12345")
+	if assert.NoError(t, err) {
+		assert.True(t, info.UserPassword)
+		assert.True(t, info.OwnerPassword)
+	}
+
+	// Without an owner password both passwords are the same
+	samePassword := NewPDFGopher(copyFile(t, "./sample_pdf/soal_no_3_protected_protected.pdf"),
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png", PasswordPDF: "12345"}),
+		WithoutBase64(),
+	)
+	assert.NoError(t, samePassword.ProcessFile())
+
+	info, err = EncryptionInfo(samePassword.OutputPath, "12345")
+	if assert.NoError(t, err) {
+		assert.True(t, info.UserPassword)
+		assert.False(t, info.OwnerPassword)
+	}
+}
+
+func TestProcessPDFPermissions(t *testing.T) {
+	pdfProcess := NewPDFGopher(copyFile(t, "./sample_pdf/soal_no_3_protected_protected.pdf"),
+		WithOptionFilePDF(OptionFilePDF{
+			QRCodePath:       "./sample_image/qr-generate.png",
+			PasswordPDF:      "12345",
+			OwnerPasswordPDF: "owner",
+		}),
+		WithPermissions(Permissions{Print: true}),
+		WithoutBase64(),
+	)
+	assert.NoError(t, pdfProcess.ProcessFile())
+
+	info, err := EncryptionInfo(pdfProcess.OutputPath, "12345")
+	if assert.NoError(t, err) {
+		assert.NotZero(t, info.Permissions&(1<<2), "print")
+		assert.NotZero(t, info.Permissions&(1<<11), "high quality print")
+		assert.Zero(t, info.Permissions&(1<<3), "modify")
+		assert.Zero(t, info.Permissions&(1<<4), "copy")
+		assert.Zero(t, info.Permissions&(1<<5), "annotate")
+	}
+
+	// Permissions aren't enforced when the owner password is the user password
+	samePassword := NewPDFGopher(copyFile(t, "./sample_pdf/soal_no_3_protected_protected.pdf"),
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png", PasswordPDF: "12345"}),
+		WithPermissions(Permissions{Print: true}),
+		WithoutBase64(),
+	)
+	assert.EqualError(t, samePassword.ProcessFile(), "permissions require an owner password different from the user password")
+}
+
+func TestProcessPDFWithEncryption(t *testing.T) {
+	// Unprotected inputs are encrypted with the given password
+	pdfProcess := NewPDFGopher(copyFile(t, "./sample_pdf/process-tree-736885__480.pdf"),
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png", OwnerPasswordPDF: "owner"}),
+		WithEncryption("secret"),
+		WithoutBase64(),
+	)
+	assert.NoError(t, pdfProcess.ProcessFile())
+	assert.True(t, pdfProcess.PDFProtection)
+
+	info, err := EncryptionInfo(pdfProcess.OutputPath, "secret")
+	if assert.NoError(t, err) {
+		assert.True(t, info.UserPassword)
+		assert.True(t, info.OwnerPassword)
+	}
+
+	// Protected inputs are opened with PasswordPDF and encrypted with the new password
+	protected := NewPDFGopher(copyFile(t, "./sample_pdf/soal_no_3_protected_protected.pdf"),
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png", PasswordPDF: "12345"}),
+		WithEncryption("secret"),
+		WithoutBase64(),
+	)
+	assert.NoError(t, protected.ProcessFile())
+
+	_, err = EncryptionInfo(protected.OutputPath, "12345")
+	assert.ErrorIs(t, err, ErrWrongPassword)
+
+	_, err = EncryptionInfo(protected.OutputPath, "secret")
+	assert.NoError(t, err)
+
+	empty := NewPDFGopher(copyFile(t, "./sample_pdf/process-tree-736885__480.pdf"),
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+		WithEncryption(""),
+	)
+	err = empty.ProcessFile()
+	assert.ErrorIs(t, err, ErrMissingPassword)
+	assert.EqualError(t, err, "password is empty: encryption requires a user password")
+}
@@ -0,0 +1,62 @@
+package pdfgopher
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/rwcarlsen/goexif/exif"
+)
+
+// WithEXIFMetadata returns an Option function that carries EXIF metadata of converted photos
+// into the PDF metadata: the capture date becomes the creation date, the camera the creator
+// and the GPS position is added to the keywords. Images without EXIF are converted as usual.
+func WithEXIFMetadata() Option {
+	return func(p *PDFProcessor) {
+		p.imageConversion.EXIFMetadata = true
+	}
+}
+
+// applyEXIFMetadata sets the PDF metadata from the EXIF data of the image file.
+// Missing EXIF data or fields are skipped.
+func applyEXIFMetadata(pdf *gofpdf.Fpdf, imageFilePath string) error {
+	file, err := os.Open(imageFilePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	data, err := exif.Decode(file)
+	if err != nil {
+		// The image has no readable EXIF data
+		return nil
+	}
+
+	if captured, err := data.DateTime(); err == nil {
+		pdf.SetCreationDate(captured)
+	}
+
+	var camera []string
+	for _, field := range []exif.FieldName{exif.Make, exif.Model} {
+		tag, err := data.Get(field)
+		if err != nil {
+			continue
+		}
+
+		value, err := tag.StringVal()
+		if err == nil && strings.TrimSpace(value) != "" {
+			camera = append(camera, strings.TrimSpace(value))
+		}
+	}
+
+	if len(camera) > 0 {
+		pdf.SetCreator(strings.Join(camera, " "), true)
+	}
+
+	if lat, long, err := data.LatLong(); err == nil {
+		pdf.SetKeywords(fmt.Sprintf("GPS:%.6f,%.6f", lat, long), true)
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package pdfgopher
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// FeatureJSON is the capability key reporting JSON output support of pdfcpu info.
+// Every other key of SupportedFeatures is a pdfcpu command name, such as "nup" or "crop".
+const FeatureJSON = "json"
+
+// versionPattern matches the version number in the output of pdfcpu version.
+var versionPattern = regexp.MustCompile(`v?\d+\.\d+\.\d+`)
+
+// SupportedFeatures probes the installed pdfcpu and returns its capabilities: every available
+// command such as "nup" or "crop" and FeatureJSON. Unlisted features are unsupported.
+func SupportedFeatures() (map[string]bool, error) {
+	version, err := runPDFCPU(context.Background(), "version")
+	if err != nil {
+		return nil, err
+	}
+
+	if !versionPattern.Match(version) {
+		return nil, fmt.Errorf("unrecognized pdfcpu version output: %s", strings.TrimSpace(string(version)))
+	}
+
+	help, err := pdfcpuHelp("help")
+	if err != nil {
+		return nil, err
+	}
+
+	infoHelp, err := pdfcpuHelp("help", "info")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseFeatures(string(help), string(infoHelp)), nil
+}
+
+// pdfcpuHelp returns the output of a pdfcpu help command with args. Older releases print the
+// help to stderr, so both outputs are combined.
+func pdfcpuHelp(args ...string) ([]byte, error) {
+	// Execute the command
+	output, err := pdfcpuCommand(context.Background(), args...).CombinedOutput()
+	if err != nil {
+		return nil, newPDFCPUError(args, output, err)
+	}
+
+	return output, nil
+}
+
+// parseFeatures builds the capability map from the pdfcpu help output and the help of the info command.
+// Both the current "Available Commands:" and the older "The commands are:" listings are recognized.
+func parseFeatures(help string, infoHelp string) map[string]bool {
+	features := make(map[string]bool)
+
+	inCommands := false
+	scanner := bufio.NewScanner(strings.NewReader(help))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasSuffix(trimmed, "Commands:") || strings.HasSuffix(trimmed, "commands are:") {
+			inCommands = true
+			continue
+		}
+
+		if !inCommands {
+			continue
+		}
+
+		if trimmed == "" {
+			// A blank line may separate the header from the listing
+			if len(features) > 0 {
+				inCommands = false
+			}
+			continue
+		}
+
+		if line == trimmed {
+			// An unindented line ends the listing
+			inCommands = false
+			continue
+		}
+
+		features[strings.Fields(trimmed)[0]] = true
+	}
+
+	features[FeatureJSON] = strings.Contains(infoHelp, "json")
+
+	return features
+}
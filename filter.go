@@ -0,0 +1,60 @@
+package pdfgopher
+
+import (
+	"image"
+	"image/color"
+	"sort"
+)
+
+// defaultDespeckleSize is the median filter kernel size used by WithDespeckle.
+const defaultDespeckleSize = 3
+
+// medianFilter applies a median filter with a size x size kernel to img.
+// It removes isolated specks, such as scanner noise, while keeping edges sharp.
+func medianFilter(img image.Image, size int) *image.RGBA {
+	bounds := img.Bounds()
+	result := image.NewRGBA(bounds)
+	radius := size / 2
+
+	window := size * size
+	reds := make([]uint8, 0, window)
+	greens := make([]uint8, 0, window)
+	blues := make([]uint8, 0, window)
+	alphas := make([]uint8, 0, window)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			reds, greens, blues, alphas = reds[:0], greens[:0], blues[:0], alphas[:0]
+
+			for dy := -radius; dy <= radius; dy++ {
+				for dx := -radius; dx <= radius; dx++ {
+					point := image.Pt(x+dx, y+dy)
+					if !point.In(bounds) {
+						continue
+					}
+
+					c := color.RGBAModel.Convert(img.At(point.X, point.Y)).(color.RGBA)
+					reds = append(reds, c.R)
+					greens = append(greens, c.G)
+					blues = append(blues, c.B)
+					alphas = append(alphas, c.A)
+				}
+			}
+
+			result.SetRGBA(x, y, color.RGBA{
+				R: median(reds),
+				G: median(greens),
+				B: median(blues),
+				A: median(alphas),
+			})
+		}
+	}
+
+	return result
+}
+
+// median returns the median value of values, sorting them in place.
+func median(values []uint8) uint8 {
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	return values[len(values)/2]
+}
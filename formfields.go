@@ -0,0 +1,66 @@
+package pdfgopher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrFormFields is returned by WithRejectFormFields for a PDF file with form fields.
+var ErrFormFields = errors.New("PDF file has form fields")
+
+var (
+	// annotationTypePattern matches the header of an annotation type in pdfcpu annotations list.
+	annotationTypePattern = regexp.MustCompile(`^\s*(\w+):$`)
+	// annotationRowPattern matches an annotation row of pdfcpu annotations list.
+	annotationRowPattern = regexp.MustCompile(`^\s*\d+\s+│`)
+)
+
+// WithRejectFormFields returns an Option function that fails processing with ErrFormFields when the
+// PDF file has form fields, i.e. widget annotations. A stamp would be placed on top of the
+// interactive fields, and pdfcpu can't flatten them into the page content. Other annotations,
+// such as links, are accepted.
+func WithRejectFormFields(reject bool) Option {
+	return func(p *PDFProcessor) {
+		p.rejectFormFields = reject
+	}
+}
+
+// checkFormFields returns ErrFormFields when the PDF file has form fields.
+func checkFormFields(ctx context.Context, filePath string) error {
+	count, err := widgetCount(ctx, filePath)
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %d fields", ErrFormFields, count)
+}
+
+// widgetCount returns the number of widget annotations of the PDF file, one for every form field.
+func widgetCount(ctx context.Context, filePath string) (int, error) {
+	output, err := runPDFCPU(ctx, "annotations", "list", "--", filePath)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	annotationType := ""
+	for _, line := range strings.Split(string(output), "\n") {
+		if match := annotationTypePattern.FindStringSubmatch(line); match != nil {
+			annotationType = match[1]
+			continue
+		}
+
+		if annotationType == "Widget" && annotationRowPattern.MatchString(line) {
+			count++
+		}
+	}
+
+	return count, nil
+}
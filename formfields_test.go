@@ -0,0 +1,45 @@
+package pdfgopher_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	. "github.com/RamdhaniMichan/PDFGopher"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessPDFRejectFormFields(t *testing.T) {
+	// Files without form fields are processed as usual
+	pdfProcess := NewPDFGopher(copyFile(t, "./sample_pdf/process-tree-736885__480.pdf"),
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+		WithRejectFormFields(true),
+		WithoutBase64(),
+	)
+	assert.NoError(t, pdfProcess.ProcessFile())
+
+	// Links are annotations, but not form fields
+	linked := filepath.Join(t.TempDir(), "linked.pdf")
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Helvetica", "", 12)
+	pdf.AddPage()
+	pdf.CellFormat(60, 10, "Example", "", 0, "L", false, 0, "https://example.com")
+	assert.NoError(t, pdf.OutputFileAndClose(linked))
+
+	pdfProcess = NewPDFGopher(linked,
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+		WithRejectFormFields(true),
+		WithoutBase64(),
+	)
+	assert.NoError(t, pdfProcess.ProcessFile())
+
+	form := NewPDFGopher(copyFile(t, "./sample_pdf/signature_field.pdf"),
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+		WithRejectFormFields(true),
+		WithoutBase64(),
+	)
+	err := form.ProcessFile()
+	assert.ErrorIs(t, err, ErrFormFields)
+	assert.EqualError(t, err, "PDF file has form fields: 1 fields")
+}
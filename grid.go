@@ -0,0 +1,122 @@
+package pdfgopher
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/qr"
+	"github.com/jung-kurt/gofpdf"
+)
+
+// Layout of the QR grid in millimeters.
+const (
+	gridMargin        = 10.0
+	gridPadding       = 4.0
+	gridCaptionHeight = 8.0
+)
+
+// QREntry represents a QR code with a caption printed below it.
+type QREntry struct {
+	Data    string
+	Caption string
+}
+
+// gridCell represents the position of a QR code in the grid.
+type gridCell struct {
+	Page int
+	X    float64
+	Y    float64
+	Size float64
+}
+
+// QRGridPage lays out the QR codes of entries in a grid with cols columns using gofpdf,
+// with each caption below its QR code. Entries that don't fit on a page continue on the next one.
+func QRGridPage(entries []QREntry, cols int, outputPath string) error {
+	if len(entries) == 0 {
+		return errors.New("no QR entries")
+	}
+
+	if cols <= 0 {
+		return fmt.Errorf("invalid number of columns: %d", cols)
+	}
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Helvetica", "", 10)
+
+	pageWidth, pageHeight := pdf.GetPageSize()
+	cells, err := gridCells(len(entries), cols, pageWidth, pageHeight)
+	if err != nil {
+		return err
+	}
+
+	page := 0
+	for i, entry := range entries {
+		cell := cells[i]
+		if cell.Page != page {
+			pdf.AddPage()
+			page = cell.Page
+		}
+
+		qrCode, err := qr.Encode(entry.Data, qr.M, qr.Auto)
+		if err != nil {
+			return err
+		}
+
+		qrCode, err = barcode.Scale(qrCode, 250, 250)
+		if err != nil {
+			return err
+		}
+
+		// gofpdf doesn't support the 16-bit PNGs of the QR encoder
+		img := image.NewGray(qrCode.Bounds())
+		draw.Draw(img, img.Bounds(), qrCode, image.Point{}, draw.Src)
+
+		var buf bytes.Buffer
+		err = png.Encode(&buf, img)
+		if err != nil {
+			return err
+		}
+
+		imageName := fmt.Sprintf("qr-%d", i)
+		pdf.RegisterImageOptionsReader(imageName, gofpdf.ImageOptions{ImageType: "PNG"}, &buf)
+		pdf.ImageOptions(imageName, cell.X, cell.Y, cell.Size, cell.Size, false, gofpdf.ImageOptions{}, 0, "")
+
+		pdf.SetXY(cell.X, cell.Y+cell.Size)
+		pdf.CellFormat(cell.Size, gridCaptionHeight, entry.Caption, "", 0, "C", false, 0, "")
+	}
+
+	return pdf.OutputFileAndClose(outputPath)
+}
+
+// gridCells computes the page and position of count QR codes laid out in cols columns.
+// Pages are numbered from 1.
+func gridCells(count int, cols int, pageWidth float64, pageHeight float64) ([]gridCell, error) {
+	cellWidth := (pageWidth - 2*gridMargin) / float64(cols)
+	size := cellWidth - 2*gridPadding
+	cellHeight := cellWidth + gridCaptionHeight
+
+	rowsPerPage := int((pageHeight - 2*gridMargin) / cellHeight)
+	if size <= 0 || rowsPerPage == 0 {
+		return nil, fmt.Errorf("too many columns to fit a QR code: %d", cols)
+	}
+
+	cells := make([]gridCell, count)
+	for i := range cells {
+		row := i / cols
+		col := i % cols
+
+		cells[i] = gridCell{
+			Page: row/rowsPerPage + 1,
+			X:    gridMargin + float64(col)*cellWidth + gridPadding,
+			Y:    gridMargin + float64(row%rowsPerPage)*cellHeight + gridPadding,
+			Size: size,
+		}
+	}
+
+	return cells, nil
+}
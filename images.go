@@ -0,0 +1,253 @@
+package pdfgopher
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"os"
+
+	"github.com/jung-kurt/gofpdf"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/tiff"
+)
+
+// defaultImageDPI is the resolution assumed for images that don't record one.
+const defaultImageDPI = 72
+
+// WithDPINormalization returns an Option function that makes ConvertImagesToPDF size every page
+// to the physical size of its image, using the DPI recorded in the image, and resample every image
+// to targetDPI. Scans of the same paper size then produce equally sized pages whatever their DPI.
+func WithDPINormalization(targetDPI int) Option {
+	return func(p *PDFProcessor) {
+		p.imageConversion.TargetDPI = targetDPI
+	}
+}
+
+// ConvertImagesToPDF converts the images to a single PDF file with one page per image.
+// Without WithDPINormalization every page is sized to the image pixels, one point per pixel.
+func ConvertImagesToPDF(imagePaths []string, outputPath string, options ...Option) error {
+	if len(imagePaths) == 0 {
+		return errors.New("no images to convert")
+	}
+
+	conversion := NewPDFGopher("", options...).imageConversion
+	if conversion.TargetDPI < 0 {
+		return fmt.Errorf("invalid target DPI: %d", conversion.TargetDPI)
+	}
+
+	pdf := gofpdf.New("P", "pt", "A4", "")
+	pdf.SetCompression(!conversion.DisableCompression)
+
+	for i, imagePath := range imagePaths {
+		data, err := os.ReadFile(imagePath)
+		if err != nil {
+			return err
+		}
+
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+
+		bounds := img.Bounds()
+		if bounds.Dx() <= 0 || bounds.Dy() <= 0 {
+			return fmt.Errorf("invalid image dimensions %dx%d: %s", bounds.Dx(), bounds.Dy(), imagePath)
+		}
+
+		pageWidth, pageHeight := float64(bounds.Dx()), float64(bounds.Dy())
+		if conversion.TargetDPI > 0 {
+			dpiX, dpiY := imageDPI(data)
+
+			// Size the page to the physical size of the image and resample it to the target DPI
+			pageWidth = pageWidth / dpiX * 72
+			pageHeight = pageHeight / dpiY * 72
+			img = resampleImage(img, pageWidth/72*float64(conversion.TargetDPI), pageHeight/72*float64(conversion.TargetDPI))
+		}
+
+		// gofpdf doesn't support 16-bit PNGs, embed every image as 8-bit RGBA
+		var buf bytes.Buffer
+		err = png.Encode(&buf, rgbaImage(img))
+		if err != nil {
+			return err
+		}
+
+		orientation := "P"
+		if pageWidth > pageHeight {
+			orientation = "L"
+		}
+
+		imageName := fmt.Sprintf("image-%d", i)
+		pdf.RegisterImageOptionsReader(imageName, gofpdf.ImageOptions{ImageType: "PNG"}, &buf)
+		pdf.AddPageFormat(orientation, gofpdf.SizeType{Wd: math.Min(pageWidth, pageHeight), Ht: math.Max(pageWidth, pageHeight)})
+		pdf.ImageOptions(imageName, 0, 0, pageWidth, pageHeight, false, gofpdf.ImageOptions{}, 0, "")
+	}
+
+	return pdf.OutputFileAndClose(outputPath)
+}
+
+// maxTIFFPages bounds the pages read from a TIFF file, guarding against cyclic page chains.
+const maxTIFFPages = 10000
+
+// decodeImageFrames decodes the image data and returns its frames with the image format.
+// Multi-page TIFF files yield one frame per page, other images a single frame.
+func decodeImageFrames(data []byte) ([]image.Image, string, error) {
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+
+	if format == "tiff" {
+		frames, err := tiffPages(data)
+		return frames, format, err
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+
+	return []image.Image{img}, format, nil
+}
+
+// tiffPages decodes every page of the TIFF data. The tiff package only decodes the first image
+// file directory, so the header is pointed at each directory of the chain in turn.
+func tiffPages(data []byte) ([]image.Image, error) {
+	var order binary.ByteOrder = binary.LittleEndian
+	if bytes.HasPrefix(data, []byte("MM")) {
+		order = binary.BigEndian
+	}
+
+	// Collect the offsets of the image file directories
+	var offsets []uint32
+	seen := make(map[uint32]bool)
+	for offset := order.Uint32(data[4:8]); offset != 0; {
+		if seen[offset] || len(offsets) == maxTIFFPages {
+			return nil, errors.New("tiff: invalid image file directory chain")
+		}
+		seen[offset] = true
+		offsets = append(offsets, offset)
+
+		if uint64(offset)+2 > uint64(len(data)) {
+			return nil, errors.New("tiff: image file directory out of range")
+		}
+		next := uint64(offset) + 2 + 12*uint64(order.Uint16(data[offset:]))
+		if next+4 > uint64(len(data)) {
+			return nil, errors.New("tiff: image file directory out of range")
+		}
+		offset = order.Uint32(data[next:])
+	}
+
+	// Decode a copy whose header points at each directory
+	page := append([]byte(nil), data...)
+	frames := make([]image.Image, 0, len(offsets))
+	for i, offset := range offsets {
+		order.PutUint32(page[4:8], offset)
+
+		img, err := tiff.Decode(bytes.NewReader(page))
+		if err != nil {
+			return nil, fmt.Errorf("tiff page %d: %w", i+1, err)
+		}
+		frames = append(frames, img)
+	}
+
+	return frames, nil
+}
+
+// resampleImage scales img to width x height pixels, rounded to whole pixels.
+func resampleImage(img image.Image, width float64, height float64) image.Image {
+	size := image.Rect(0, 0, int(math.Max(1, math.Round(width))), int(math.Max(1, math.Round(height))))
+	if size.Size() == img.Bounds().Size() {
+		return img
+	}
+
+	resampled := image.NewRGBA(size)
+	draw.CatmullRom.Scale(resampled, size, img, img.Bounds(), draw.Src, nil)
+
+	return resampled
+}
+
+// rgbaImage returns img as an 8-bit RGBA image.
+func rgbaImage(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok {
+		return rgba
+	}
+
+	rgba := image.NewRGBA(img.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+
+	return rgba
+}
+
+// imageDPI returns the horizontal and vertical resolution recorded in PNG or JPEG image data.
+// Images that don't record a resolution are assumed to be 72 DPI.
+func imageDPI(data []byte) (float64, float64) {
+	var dpiX, dpiY float64
+
+	switch {
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		dpiX, dpiY = pngDPI(data)
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8}):
+		dpiX, dpiY = jpegDPI(data)
+	}
+
+	if dpiX <= 0 || dpiY <= 0 {
+		return defaultImageDPI, defaultImageDPI
+	}
+
+	return dpiX, dpiY
+}
+
+// pngDPI reads the resolution from the pHYs chunk of PNG data.
+func pngDPI(data []byte) (float64, float64) {
+	for offset := 8; offset+8 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[offset:]))
+		chunk := string(data[offset+4 : offset+8])
+		body := data[offset+8:]
+
+		if length < 0 || length > len(body) || chunk == "IDAT" {
+			break
+		}
+
+		// Only pixels per meter can be converted to DPI
+		if chunk == "pHYs" && length >= 9 && body[8] == 1 {
+			return float64(binary.BigEndian.Uint32(body)) * 0.0254, float64(binary.BigEndian.Uint32(body[4:])) * 0.0254
+		}
+
+		// Skip the chunk data and CRC
+		offset += 12 + length
+	}
+
+	return 0, 0
+}
+
+// jpegDPI reads the resolution from the JFIF APP0 segment of JPEG data.
+func jpegDPI(data []byte) (float64, float64) {
+	for offset := 2; offset+4 <= len(data) && data[offset] == 0xFF; {
+		marker := data[offset+1]
+		length := int(binary.BigEndian.Uint16(data[offset+2:]))
+		if marker == 0xDA || length < 2 || offset+2+length > len(data) {
+			break
+		}
+
+		segment := data[offset+4 : offset+2+length]
+		if marker == 0xE0 && len(segment) >= 12 && bytes.HasPrefix(segment, []byte("JFIF\x00")) {
+			x, y := float64(binary.BigEndian.Uint16(segment[8:])), float64(binary.BigEndian.Uint16(segment[10:]))
+
+			switch segment[7] {
+			case 1:
+				return x, y
+			case 2:
+				// Dots per centimeter
+				return x * 2.54, y * 2.54
+			}
+		}
+
+		offset += 2 + length
+	}
+
+	return 0, 0
+}
@@ -0,0 +1,57 @@
+package pdfgopher
+
+import "context"
+
+// PDFInfo represents basic information about a PDF file.
+type PDFInfo struct {
+	PageCount int
+	// PageWidth and PageHeight are the media box size of the first page in points.
+	PageWidth  float64
+	PageHeight float64
+	Encrypted  bool
+	// Version is the PDF version of the file, such as "1.7".
+	Version string
+}
+
+// PageCount returns the number of pages of the PDF file.
+// A protected file is opened with the password, which is ignored for unprotected files.
+func PageCount(filePath string, password ...string) (int, error) {
+	info, err := readPDFInfo(context.Background(), filePath, "", passwordFlags(password)...)
+	if err != nil {
+		return 0, err
+	}
+
+	return info.PageCount, nil
+}
+
+// ReadPDFInfo returns the page count, first page size, encryption status and PDF version
+// of the PDF file. A protected file is opened with the password.
+func ReadPDFInfo(filePath string, password ...string) (*PDFInfo, error) {
+	info, err := readPDFInfo(context.Background(), filePath, "1", passwordFlags(password)...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PDFInfo{
+		PageCount: info.PageCount,
+		Encrypted: info.Encrypted,
+		Version:   info.Version,
+	}
+
+	if boundaries, ok := info.PageBoundaries["1"]; ok {
+		rect := boundaries.MediaBox.Rect
+		result.PageWidth = rect.UR.X - rect.LL.X
+		result.PageHeight = rect.UR.Y - rect.LL.Y
+	}
+
+	return result, nil
+}
+
+// passwordFlags returns the pdfcpu flags opening a file with the first password, if any.
+func passwordFlags(password []string) []string {
+	if len(password) == 0 || password[0] == "" {
+		return nil
+	}
+
+	return []string{"--upw", password[0]}
+}
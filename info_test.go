@@ -0,0 +1,37 @@
+package pdfgopher_test
+
+import (
+	"testing"
+
+	. "github.com/RamdhaniMichan/PDFGopher"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPageCount(t *testing.T) {
+	count, err := PageCount(multiPagePDF(t, 4))
+	assert.NoError(t, err)
+	assert.Equal(t, 4, count)
+
+	count, err = PageCount("./sample_pdf/soal_no_3_protected_protected.pdf", "12345")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	_, err = PageCount("./sample_pdf/soal_no_3_protected_protected.pdf")
+	assert.Error(t, err)
+}
+
+func TestReadPDFInfo(t *testing.T) {
+	info, err := ReadPDFInfo(multiPagePDF(t, 2))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, info.PageCount)
+	assert.InDelta(t, 595.28, info.PageWidth, 0.01)
+	assert.InDelta(t, 841.89, info.PageHeight, 0.01)
+	assert.False(t, info.Encrypted)
+	assert.NotEmpty(t, info.Version)
+
+	info, err = ReadPDFInfo("./sample_pdf/soal_no_3_protected_protected.pdf", "12345")
+	assert.NoError(t, err)
+	assert.True(t, info.Encrypted)
+	assert.Equal(t, "1.7", info.Version)
+}
@@ -0,0 +1,202 @@
+package pdfgopher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// tocBookmark represents a single entry of a pdfcpu bookmarks JSON file.
+type tocBookmark struct {
+	Title string `json:"title"`
+	Page  int    `json:"page"`
+}
+
+// tocRowsPerPage is the number of titles listed on every table-of-contents page.
+const tocRowsPerPage = 30
+
+// MergeWithTOC merges the input PDFs into output and prepends a table of contents, one page for
+// every 30 titles. Each title gets a bookmark that jumps to the first page of its document.
+// Every input must exist and be a PDF file, checked by its content. The output file must not exist yet.
+func MergeWithTOC(inputs []string, titles []string, output string) error {
+	return mergeWithTOC(context.Background(), inputs, titles, output)
+}
+
+// mergeWithTOC merges the input PDFs behind a table of contents like MergeWithTOC, running pdfcpu with ctx.
+func mergeWithTOC(ctx context.Context, inputs []string, titles []string, output string) error {
+	if len(titles) != len(inputs) {
+		return fmt.Errorf("titles length %d does not match inputs length %d", len(titles), len(inputs))
+	}
+
+	err := validateMergeInputs(inputs)
+	if err != nil {
+		return err
+	}
+
+	// Calculate the start page of every document behind the table of contents
+	bookmarks := make([]tocBookmark, len(inputs))
+	startPage := (len(inputs)+tocRowsPerPage-1)/tocRowsPerPage + 1
+	for i, input := range inputs {
+		count, err := pageCount(ctx, input)
+		if err != nil {
+			return err
+		}
+
+		bookmarks[i] = tocBookmark{Title: titles[i], Page: startPage}
+		startPage += count
+	}
+
+	// Generate the table-of-contents pages
+	tocFile, err := os.CreateTemp("", "toc-*.pdf")
+	if err != nil {
+		return err
+	}
+	tocFile.Close()
+	defer os.Remove(tocFile.Name())
+
+	err = generateTOCPages(tocFile.Name(), bookmarks)
+	if err != nil {
+		return err
+	}
+
+	// Merge the table of contents with the input files
+	err = mergePDFs(ctx, append([]string{tocFile.Name()}, inputs...), output)
+	if err != nil {
+		return err
+	}
+
+	// Write the bookmarks and import them into the merged file
+	bookmarkFile, err := os.CreateTemp("", "toc-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(bookmarkFile.Name())
+
+	err = json.NewEncoder(bookmarkFile).Encode(map[string][]tocBookmark{"bookmarks": bookmarks})
+	bookmarkFile.Close()
+	if err != nil {
+		return err
+	}
+
+	_, err = runPDFCPU(ctx, "bookmarks", "import", "--replace", output, bookmarkFile.Name())
+	return err
+}
+
+// MergePDFs merges the input PDFs into output in the given order.
+// Every input must exist and be a PDF file, checked by its content. The output file must not exist yet.
+func MergePDFs(inputs []string, output string) error {
+	return mergePDFs(context.Background(), inputs, output)
+}
+
+// mergePDFs merges the input PDFs into output like MergePDFs, running pdfcpu with ctx.
+func mergePDFs(ctx context.Context, inputs []string, output string) error {
+	err := validateMergeInputs(inputs)
+	if err != nil {
+		return err
+	}
+
+	_, err = runPDFCPU(ctx, append([]string{"merge", output}, inputs...)...)
+	return err
+}
+
+// validateMergeInputs checks that there are inputs and that every input exists and is a PDF file.
+func validateMergeInputs(inputs []string) error {
+	if len(inputs) == 0 {
+		return errors.New("no input files to merge")
+	}
+
+	for _, input := range inputs {
+		if _, err := os.Stat(input); err != nil {
+			if os.IsNotExist(err) {
+				return fmt.Errorf("file not found: %s", input)
+			}
+			return err
+		}
+
+		if sniffFile(input) != PDF {
+			return fmt.Errorf("not a PDF file: %s", input)
+		}
+	}
+
+	return nil
+}
+
+// WithMergedImages returns an Option function that converts the images at paths to PDF and
+// appends them after the image at FilePath, so the combined PDF is stamped once.
+// It only applies to image input files.
+func WithMergedImages(paths ...string) Option {
+	return func(p *PDFProcessor) {
+		p.mergedImages = paths
+	}
+}
+
+// appendMergedImages converts the merged images to PDF and appends them to the PDF file
+// converted from FilePath.
+func (p *PDFProcessor) appendMergedImages(ctx context.Context, pdfFilePath string) error {
+	inputs := []string{pdfFilePath}
+	for _, imagePath := range p.mergedImages {
+		if getFileType(imagePath) != Image {
+			return fmt.Errorf("not an image file: %s", imagePath)
+		}
+
+		converted, err := imageConverter(imagePath, p.tempDir, p.imageConversion)
+		if err != nil {
+			return err
+		}
+		p.trackTempFile(converted)
+
+		inputs = append(inputs, converted)
+	}
+
+	// Merge next to the converted file and replace it once the merge succeeded,
+	// pdfcpu refuses to write to an existing file
+	dir, err := os.MkdirTemp(filepath.Dir(pdfFilePath), ".pdfgopher-merge-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	merged := filepath.Join(dir, "merged.pdf")
+	err = mergePDFs(ctx, inputs, merged)
+	if err != nil {
+		return err
+	}
+
+	// A dry run only prints the merge, the converted file is left unchanged
+	if _, ok := ctx.Value(dryRunKey{}).(io.Writer); ok {
+		return nil
+	}
+
+	return os.Rename(merged, pdfFilePath)
+}
+
+// generateTOCPages writes the table of contents listing every bookmark with its start page,
+// tocRowsPerPage bookmarks on every page.
+func generateTOCPages(filePath string, bookmarks []tocBookmark) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	// Pages are broken explicitly, the start pages depend on the number of TOC pages
+	pdf.SetAutoPageBreak(false, 0)
+
+	for i, bookmark := range bookmarks {
+		if i%tocRowsPerPage == 0 {
+			pdf.AddPage()
+
+			pdf.SetFont("Helvetica", "B", 16)
+			pdf.CellFormat(0, 12, "Table of Contents", "", 1, "C", false, 0, "")
+			pdf.Ln(4)
+
+			pdf.SetFont("Helvetica", "", 12)
+		}
+
+		pdf.CellFormat(160, 8, bookmark.Title, "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 8, fmt.Sprintf("%d", bookmark.Page), "", 1, "R", false, 0, "")
+	}
+
+	return pdf.OutputFileAndClose(filePath)
+}
@@ -0,0 +1,168 @@
+package pdfgopher_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	. "github.com/RamdhaniMichan/PDFGopher"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeWithTOC(t *testing.T) {
+	input := "./sample_pdf/process-tree-736885__480.pdf"
+	output := filepath.Join(t.TempDir(), "merged.pdf")
+
+	err := MergeWithTOC([]string{input, input}, []string{"First", "Second"}, output)
+	assert.NoError(t, err)
+
+	// Export the bookmarks of the merged file
+	bookmarkFile := filepath.Join(t.TempDir(), "bookmarks.json")
+	out, err := exec.Command("pdfcpu", "bookmarks", "export", output, bookmarkFile).CombinedOutput()
+	assert.NoError(t, err, string(out))
+
+	var result struct {
+		Bookmarks []struct {
+			Title string `json:"title"`
+			Page  int    `json:"page"`
+		} `json:"bookmarks"`
+	}
+
+	data, err := os.ReadFile(bookmarkFile)
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(data, &result))
+
+	// The TOC page comes first, so every document is shifted by one page
+	if assert.Len(t, result.Bookmarks, 2) {
+		assert.Equal(t, "First", result.Bookmarks[0].Title)
+		assert.Equal(t, 2, result.Bookmarks[0].Page)
+		assert.Equal(t, "Second", result.Bookmarks[1].Title)
+		assert.Equal(t, 3, result.Bookmarks[1].Page)
+	}
+
+	// The extra leading page lists every title with its start page
+	assert.Equal(t, 3, readInfo(t, output).PageCount)
+
+	pages := pageStreams(t, output)
+	if assert.Len(t, pages, 3) {
+		assert.Contains(t, pages[0], "(Table of Contents)")
+		assert.Regexp(t, `(?s)\(First\).*\(2\).*\(Second\).*\(3\)`, pages[0])
+	}
+}
+
+func TestMergeWithTOCOverflow(t *testing.T) {
+	input := multiPagePDF(t, 1)
+	output := filepath.Join(t.TempDir(), "merged.pdf")
+
+	// 70 titles take three TOC pages
+	inputs := make([]string, 70)
+	titles := make([]string, 70)
+	for i := range inputs {
+		inputs[i] = input
+		titles[i] = fmt.Sprintf("Document %d", i+1)
+	}
+
+	err := MergeWithTOC(inputs, titles, output)
+	assert.NoError(t, err)
+	assert.Equal(t, 73, readInfo(t, output).PageCount)
+
+	bookmarkFile := filepath.Join(t.TempDir(), "bookmarks.json")
+	out, err := exec.Command("pdfcpu", "bookmarks", "export", output, bookmarkFile).CombinedOutput()
+	assert.NoError(t, err, string(out))
+
+	var result struct {
+		Bookmarks []struct {
+			Title string `json:"title"`
+			Page  int    `json:"page"`
+		} `json:"bookmarks"`
+	}
+
+	data, err := os.ReadFile(bookmarkFile)
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(data, &result))
+
+	if assert.Len(t, result.Bookmarks, 70) {
+		assert.Equal(t, 4, result.Bookmarks[0].Page)
+		assert.Equal(t, "Document 70", result.Bookmarks[69].Title)
+		assert.Equal(t, 73, result.Bookmarks[69].Page)
+	}
+
+	// Every TOC page lists its own titles, the documents follow
+	pages := pageStreams(t, output)
+	if assert.Len(t, pages, 73) {
+		assert.Contains(t, pages[0], "(Document 30)")
+		assert.NotContains(t, pages[0], "(Document 31)")
+		assert.Contains(t, pages[2], "(Document 70)")
+		assert.Contains(t, pages[3], "(Page 1)")
+	}
+
+	err = MergeWithTOC([]string{input, "./sample_image/qr-generate.png"}, []string{"PDF", "Image"}, output)
+	assert.EqualError(t, err, "not a PDF file: ./sample_image/qr-generate.png")
+}
+
+func TestMergeWithTOCTitlesMismatch(t *testing.T) {
+	err := MergeWithTOC([]string{"a.pdf", "b.pdf"}, []string{"Only one"}, "out.pdf")
+
+	assert.Error(t, err)
+}
+
+func TestMergePDFs(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "merged.pdf")
+
+	err := MergePDFs([]string{multiPagePDF(t, 2), "./sample_pdf/process-tree-736885__480.pdf"}, output)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, readInfo(t, output).PageCount)
+
+	assert.EqualError(t, MergePDFs(nil, output), "no input files to merge")
+
+	missing := filepath.Join(t.TempDir(), "missing.pdf")
+	assert.EqualError(t, MergePDFs([]string{output, missing}, output), "file not found: "+missing)
+
+	assert.EqualError(t, MergePDFs([]string{"./sample_image/qr-generate.png"}, output), "not a PDF file: ./sample_image/qr-generate.png")
+}
+
+func TestProcessFileWithMergedImages(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "page-1.jpg")
+	second := filepath.Join(dir, "page-2.png")
+	copyInto(t, "./sample_image/tree-736885__480.jpg", first)
+	copyInto(t, "./sample_image/qr-generate.png", second)
+
+	pdfProcess := NewPDFGopher(first,
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+		WithMergedImages(second),
+		WithoutBase64(),
+	)
+	defer pdfProcess.Close()
+
+	assert.NoError(t, pdfProcess.ProcessFile())
+
+	pages := pageStreams(t, pdfProcess.OutputPath)
+	if assert.Len(t, pages, 2) {
+		for _, page := range pages {
+			assert.Contains(t, page, "/Watermark")
+		}
+	}
+
+	// A dry run prints the merge and leaves the converted image alone
+	var commands bytes.Buffer
+	dryRun := NewPDFGopher(first,
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+		WithMergedImages(second),
+		WithDryRun(&commands),
+		WithoutBase64(),
+	)
+	defer dryRun.Close()
+
+	assert.NoError(t, dryRun.ProcessFile())
+	assert.Regexp(t, `(?m)^pdfcpu merge \S+/merged\.pdf \S+\.pdf \S+\.pdf$`, commands.String())
+	assert.Equal(t, 1, readInfo(t, dryRun.OutputPath).PageCount)
+
+	err := NewPDFGopher("./sample_pdf/process-tree-736885__480.pdf", WithMergedImages(second)).ProcessFile()
+	assert.EqualError(t, err, "merged images require an image input file, got process-tree-736885__480.pdf")
+}
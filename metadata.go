@@ -0,0 +1,117 @@
+package pdfgopher
+
+import (
+	"context"
+	"strings"
+)
+
+// volatileMetadataFields lists the metadata fields that change whenever a PDF file is written.
+var volatileMetadataFields = []string{"CreationDate", "ModDate"}
+
+// DiffOption represents a functional option for DiffMetadata.
+type DiffOption func(*diffConfig)
+
+// diffConfig holds the options applied when comparing metadata.
+type diffConfig struct {
+	ignoreFields map[string]bool
+}
+
+// IgnoreVolatileFields returns a DiffOption function that skips the creation and modification
+// dates, which differ between any two writes of the same document.
+func IgnoreVolatileFields() DiffOption {
+	return IgnoreFields(volatileMetadataFields...)
+}
+
+// IgnoreFields returns a DiffOption function that skips the given metadata fields.
+func IgnoreFields(fields ...string) DiffOption {
+	return func(c *diffConfig) {
+		for _, field := range fields {
+			c.ignoreFields[field] = true
+		}
+	}
+}
+
+// DiffMetadata compares the document information of the PDF files a and b and returns the
+// (a, b) values of every field that differs. Fields are named after the info dictionary keys,
+// such as Title and ModDate, custom properties by their own key. A field missing from one file
+// is reported with an empty value.
+func DiffMetadata(a, b string, options ...DiffOption) (map[string][2]string, error) {
+	config := diffConfig{ignoreFields: make(map[string]bool)}
+	for _, opt := range options {
+		opt(&config)
+	}
+
+	ctx := context.Background()
+
+	fieldsA, err := readMetadataFields(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+
+	fieldsB, err := readMetadataFields(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := make(map[string][2]string)
+	for _, fields := range []map[string]string{fieldsA, fieldsB} {
+		for field := range fields {
+			if config.ignoreFields[field] || fieldsA[field] == fieldsB[field] {
+				continue
+			}
+
+			diff[field] = [2]string{fieldsA[field], fieldsB[field]}
+		}
+	}
+
+	return diff, nil
+}
+
+// ReadMetadata reads the title, author, subject, keywords and creator of the PDF file.
+// A protected file is opened with the password, which is ignored for unprotected files.
+// A file without document information yields empty fields.
+func ReadMetadata(filePath string, password ...string) (*OptionMetadataPDF, error) {
+	info, err := readPDFInfo(context.Background(), filePath, "", passwordFlags(password)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OptionMetadataPDF{
+		Title:    info.Title,
+		Author:   info.Author,
+		Subject:  info.Subject,
+		Keywords: strings.Join(info.Keywords, ", "),
+		Creator:  info.Creator,
+	}, nil
+}
+
+// readMetadataFields returns the document information of the PDF file by info dictionary key.
+// Empty fields are left out.
+func readMetadataFields(ctx context.Context, filePath string) (map[string]string, error) {
+	info, err := readPDFInfo(ctx, filePath, "")
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]string)
+	for key, value := range info.Properties {
+		fields[key] = value
+	}
+
+	for key, value := range map[string]string{
+		"Title":        info.Title,
+		"Author":       info.Author,
+		"Subject":      info.Subject,
+		"Keywords":     strings.Join(info.Keywords, ", "),
+		"Creator":      info.Creator,
+		"Producer":     info.Producer,
+		"CreationDate": info.CreationDate,
+		"ModDate":      info.ModificationDate,
+	} {
+		if value != "" {
+			fields[key] = value
+		}
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,49 @@
+package pdfgopher_test
+
+import (
+	"os/exec"
+	"testing"
+
+	. "github.com/RamdhaniMichan/PDFGopher"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffMetadata(t *testing.T) {
+	a := copyFile(t, "./sample_pdf/process-tree-736885__480.pdf")
+	b := copyFile(t, "./sample_pdf/process-tree-736885__480.pdf")
+
+	assert.NoError(t, exec.Command("pdfcpu", "properties", "add", a, "Title = Draft", "Author = Gopher").Run())
+	assert.NoError(t, exec.Command("pdfcpu", "properties", "add", b, "Title = Final", "Author = Gopher").Run())
+
+	diff, err := DiffMetadata(a, b, IgnoreVolatileFields())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][2]string{"Title": {"Draft", "Final"}}, diff)
+
+	diff, err = DiffMetadata(a, a)
+	assert.NoError(t, err)
+	assert.Empty(t, diff)
+
+	diff, err = DiffMetadata(a, b, IgnoreFields("Title", "CreationDate", "ModDate"))
+	assert.NoError(t, err)
+	assert.Empty(t, diff)
+}
+
+func TestReadMetadata(t *testing.T) {
+	metadata, err := ReadMetadata("./sample_pdf/process-tree-736885__480.pdf")
+	assert.NoError(t, err)
+	assert.Equal(t, &OptionMetadataPDF{Title: "Me to", Author: "Me to", Subject: "Me to"}, metadata)
+
+	// Protected files need the password
+	_, err = ReadMetadata("./sample_pdf/soal_no_3_protected_protected.pdf")
+	assert.Error(t, err)
+
+	metadata, err = ReadMetadata("./sample_pdf/soal_no_3_protected_protected.pdf", "12345")
+	assert.NoError(t, err)
+	assert.Equal(t, "Me to", metadata.Title)
+
+	// A file without a title, author or subject yields empty fields
+	metadata, err = ReadMetadata(multiPagePDF(t, 1))
+	assert.NoError(t, err)
+	assert.True(t, IsStructEmpty(metadata))
+}
@@ -0,0 +1,36 @@
+package pdfgopher
+
+import (
+	"context"
+	"os"
+)
+
+// WithOptimize returns an Option function that runs pdfcpu optimize as the last step before
+// encryption, removing redundant objects and duplicate resources such as fonts and images.
+// SizeBefore and SizeAfter report the savings. pdfcpu-cli doesn't linearize PDF files.
+func WithOptimize() Option {
+	return func(p *PDFProcessor) {
+		p.optimize = true
+	}
+}
+
+// optimizePDF optimizes the PDF file in place and returns its size in bytes before and after.
+func optimizePDF(ctx context.Context, filePath string) (before int64, after int64, err error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return 0, 0, err
+	}
+	before = info.Size()
+
+	_, err = runPDFCPU(ctx, "optimize", "--", filePath)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	info, err = os.Stat(filePath)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return before, info.Size(), nil
+}
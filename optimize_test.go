@@ -0,0 +1,35 @@
+package pdfgopher_test
+
+import (
+	"os"
+	"testing"
+
+	. "github.com/RamdhaniMichan/PDFGopher"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessPDFOptimize(t *testing.T) {
+	pdfProcess := NewPDFGopher(copyFile(t, "./sample_pdf/process-tree-736885__480.pdf"),
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+		WithOptimize(),
+		WithoutBase64(),
+	)
+	assert.NoError(t, pdfProcess.ProcessFile())
+	assert.NotZero(t, pdfProcess.SizeBefore)
+	assert.LessOrEqual(t, pdfProcess.SizeAfter, pdfProcess.SizeBefore)
+
+	info, err := os.Stat(pdfProcess.OutputPath)
+	if assert.NoError(t, err) {
+		assert.Equal(t, info.Size(), pdfProcess.SizeAfter)
+	}
+
+	// The sizes are only reported with WithOptimize
+	plain := NewPDFGopher(copyFile(t, "./sample_pdf/process-tree-736885__480.pdf"),
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+		WithoutBase64(),
+	)
+	assert.NoError(t, plain.ProcessFile())
+	assert.Zero(t, plain.SizeBefore)
+	assert.Zero(t, plain.SizeAfter)
+}
@@ -1,22 +1,36 @@
 package pdfgopher
 
 import (
+	"bytes"
+	"context"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"image"
+	"image/color"
+	"image/jpeg"
 	"reflect"
 
 	"image/png"
+	"io"
+	"math"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/boombuler/barcode"
 	"github.com/boombuler/barcode/qr"
 	"github.com/jung-kurt/gofpdf"
 	"golang.org/x/image/draw"
+
+	// Register the WebP decoder for image.Decode
+	_ "golang.org/x/image/webp"
 )
 
 // FileType represents the type of file.
@@ -33,27 +47,206 @@ const (
 	Document FileType = "document"
 )
 
+// StampPosition represents where a stamp is anchored on the page.
+type StampPosition string
+
+// Constants for the stamp positions, their values are the pdfcpu anchors.
+const (
+	TopLeft      StampPosition = "tl"
+	TopCenter    StampPosition = "tc"
+	TopRight     StampPosition = "tr"
+	Left         StampPosition = "l"
+	Center       StampPosition = "c"
+	Right        StampPosition = "r"
+	BottomLeft   StampPosition = "bl"
+	BottomCenter StampPosition = "bc"
+	BottomRight  StampPosition = "br"
+)
+
+// String returns the pdfcpu anchor of the position.
+func (s StampPosition) String() string {
+	return string(s)
+}
+
+// stampPositions lists the pdfcpu anchors a stamp can be placed at.
+var stampPositions = []StampPosition{TopLeft, TopCenter, TopRight, Left, Center, Right, BottomLeft, BottomCenter, BottomRight}
+
+// validateStampPosition checks that position is one of the pdfcpu anchors, so a typo fails
+// with the valid values instead of an opaque pdfcpu error.
+func validateStampPosition(position StampPosition) error {
+	names := make([]string, len(stampPositions))
+	for i, valid := range stampPositions {
+		if position == valid {
+			return nil
+		}
+		names[i] = string(valid)
+	}
+
+	return fmt.Errorf("invalid stamp position: %q, expected one of %s", position, strings.Join(names, ", "))
+}
+
+// StampPolicy represents how stamps already present in a PDF file are handled when stamping it again.
+type StampPolicy string
+
+// Constants for the existing stamp policies.
+const (
+	// StampLayer adds the new stamp on top of existing stamps.
+	StampLayer StampPolicy = "layer"
+	// StampPreserve keeps existing stamps and skips adding a new one.
+	StampPreserve StampPolicy = "preserve"
+	// StampReplace removes existing stamps before adding the new one.
+	StampReplace StampPolicy = "replace"
+)
+
+// WritePolicy represents whether processing modifies the input PDF file or a copy of it.
+type WritePolicy string
+
+// Constants for the write policies.
+const (
+	// CopyOnWrite processes a copy of the input file next to it, named with the "process-" prefix
+	// and a random suffix.
+	CopyOnWrite WritePolicy = "copy-on-write"
+	// InPlace processes the input file itself.
+	InPlace WritePolicy = "in-place"
+)
+
+// ErrWrongPassword is returned when a password doesn't open a protected PDF file.
+var ErrWrongPassword = errors.New("wrong password")
+
+// ErrUnsupportedFileType is returned for input files that are neither PDF files, images nor
+// documents, wrapped with the extension of the file.
+var ErrUnsupportedFileType = errors.New("unsupported file type")
+
+// ErrMissingQRCode is returned when there is neither a QR code nor another stamp to apply.
+var ErrMissingQRCode = errors.New("QR Code is empty")
+
+// ErrMissingPassword is returned when a password is needed to open or encrypt a PDF file but
+// none was given.
+var ErrMissingPassword = errors.New("password is empty")
+
+// ErrPageCountChanged is returned when an operation dropped or duplicated pages of a PDF file.
+var ErrPageCountChanged = errors.New("page count changed unexpectedly")
+
 // PDFProcessor provides operations related to PDF files.
+// Its JSON encoding summarizes the last run, the options and the Base64 output are left out.
 type PDFProcessor struct {
-	FilePath      string
-	Base64Output  string
-	PDFProtection bool
-	*OptionFilePDF
-	*OptionMetadataPDF
+	FilePath      string `json:"input"`
+	OutputPath    string `json:"output,omitempty"`
+	Base64Output  string `json:"-"`
+	PDFProtection bool   `json:"encrypted"`
+	// PageCount is the page count of the output.
+	PageCount int `json:"pageCount,omitempty"`
+	// ProcessError is the error of the last run, empty when it succeeded.
+	ProcessError string `json:"error,omitempty"`
+	// SizeBefore and SizeAfter are the sizes in bytes of the output before and after
+	// WithOptimize, zero without it.
+	SizeBefore         int64 `json:"sizeBefore,omitempty"`
+	SizeAfter          int64 `json:"sizeAfter,omitempty"`
+	*OptionFilePDF     `json:"-"`
+	*OptionMetadataPDF `json:"-"`
+
+	skipBase64         bool
+	validationMode     string
+	imageConversion    imageConversion
+	recordSource       bool
+	jobID              string
+	portraitStamp      *StampSpec
+	stamps             []StampSpec
+	outputPath         string
+	overwrite          bool
+	landscapeStamp     *StampSpec
+	cacheDir           string
+	qrData             string
+	qrIconPath         string
+	existingStamps     StampPolicy
+	writePolicy        WritePolicy
+	timestampFooter    bool
+	conversionTimeout  time.Duration
+	fileFilter         []string
+	tempFiles          []string
+	pdfcpuConfigDir    string
+	extraStampFlags    []string
+	extraEncryptFlags  []string
+	extraValidateFlags []string
+	concurrency        int
+	encryptWhen        func(text string) bool
+	checkFileType      bool
+	textStamp          string
+	textStampPosition  StampPosition
+	checkDependencies  bool
+	mergedImages       []string
+	permissions        *Permissions
+	rotateAngle        int
+	rotatePages        string
+	dryRun             io.Writer
+	forceEncryption    bool
+	encryptionPassword string
+	rejectFormFields   bool
+	optimize           bool
+	tempDir            string
+	pdfStampPath       string
+	pdfStampPage       int
+}
+
+// imageConversion holds the options applied when converting an image to PDF.
+type imageConversion struct {
+	// DespeckleSize is the median filter kernel size, zero disables despeckling.
+	DespeckleSize int
+	// DisableCompression writes uncompressed PDF streams, which is useful for debugging.
+	DisableCompression bool
+	// EXIFMetadata carries the EXIF capture date, camera and GPS position into the PDF metadata.
+	EXIFMetadata bool
+	// TargetDPI normalizes the images of ConvertImagesToPDF to a common resolution, zero keeps the pixel size.
+	TargetDPI int
+	// PageSize is the page size of a converted image such as "Letter", A4 when empty.
+	PageSize string
+	// Orientation is "P", "L" or "auto" to follow the image, portrait when empty.
+	Orientation string
+	// ImageDPI places converted images at that resolution, zero spans the page width.
+	ImageDPI int
+	// JPEGQuality re-encodes JPEG images at that quality from 1 to 100, zero embeds them unchanged.
+	JPEGQuality int
 }
 
 // OptionMetadataPDF represents options for modifying PDF metadata.
+// Empty fields are skipped, so they keep the values already in the PDF file.
+// The Producer can't be set, pdfcpu records itself as the producer of every file it writes.
 type OptionMetadataPDF struct {
 	Title   string
 	Author  string
 	Subject string
+	// Keywords is a comma separated list that replaces the existing keywords.
+	Keywords string
+	Creator  string
 }
 
 // OptionFilePDF represents options for working with PDF files.
 type OptionFilePDF struct {
-	PasswordPDF   string
-	QRCodePath    string
-	StampPosition string
+	// PasswordPDF is the user password, it opens a protected input and the encrypted output.
+	PasswordPDF string
+	// OwnerPasswordPDF is the owner password of the encrypted output, needed to change its
+	// permissions. The user password is used when it is empty.
+	OwnerPasswordPDF string
+	QRCodePath       string
+	StampPosition    StampPosition
+	// StampWidthRatio sizes the QR code relative to each page width, e.g. 0.15 for 15%.
+	// Zero keeps the fixed pdfcpu scale.
+	StampWidthRatio float64
+	// StampScale is the relative pdfcpu scale of the QR code, 0.1 when zero.
+	StampScale float64
+	// StampRotation rotates the QR code by the given degrees.
+	StampRotation float64
+	// StampOffsetX and StampOffsetY move the QR code from StampPosition by that many points,
+	// to the right and up for positive values.
+	StampOffsetX float64
+	StampOffsetY float64
+	// StampOpacity ranges from 0 to 1, zero keeps the QR code fully opaque.
+	StampOpacity float64
+	// StampPages selects the pages to stamp with pdfcpu page selectors such as "1", "l" for the last
+	// page or "1-3,5", "even,odd" by default for every page.
+	StampPages string
+	// StampAltText describes the QR code to screen readers.
+	StampAltText string
 }
 
 // NewPDFGopher constructor to retrieve struct PDFProcessor
@@ -61,9 +254,13 @@ func NewPDFGopher(filePath string, options ...Option) *PDFProcessor {
 	option := &PDFProcessor{
 		FilePath: filePath,
 		OptionFilePDF: &OptionFilePDF{
-			StampPosition: "br",
+			StampPosition: BottomRight,
+			StampPages:    "even,odd",
 		},
 		OptionMetadataPDF: &OptionMetadataPDF{},
+		validationMode:    "relaxed",
+		existingStamps:    StampLayer,
+		writePolicy:       CopyOnWrite,
 	}
 
 	for _, opt := range options {
@@ -81,283 +278,2243 @@ func WithOptionMetadataPDF(value OptionMetadataPDF) Option {
 }
 
 // WithOptionFilePDF returns an Option function that sets the OptionFilePDF value.
+// Only the non-zero fields of value are set, the others keep their defaults or earlier values.
 func WithOptionFilePDF(value OptionFilePDF) Option {
 	return func(p *PDFProcessor) {
 		v := reflect.ValueOf(value)
 		for i := 0; i < v.NumField(); i++ {
 			field := v.Field(i)
-			if field.String() != "" {
+			if !field.IsZero() {
 				reflect.ValueOf(p.OptionFilePDF).Elem().Field(i).Set(field)
 			}
 		}
 	}
 }
 
+// WithStampPosition returns an Option function that sets the stamp position from a pdfcpu anchor
+// such as "br", for callers that keep positions as plain strings.
+func WithStampPosition(position string) Option {
+	return func(p *PDFProcessor) {
+		p.StampPosition = StampPosition(position)
+	}
+}
+
+// WithoutBase64 returns an Option function that skips encoding the processed file into Base64Output.
+// The processed file is still available through OutputPath.
+func WithoutBase64() Option {
+	return WithBase64Output(false)
+}
+
+// WithBase64Output returns an Option function that sets whether the processed file is encoded
+// into Base64Output, which is enabled by default. Disable it for large files that are read
+// through OutputPath or WriteTo, to avoid holding the encoded file in memory.
+func WithBase64Output(enabled bool) Option {
+	return func(p *PDFProcessor) {
+		p.skipBase64 = !enabled
+	}
+}
+
+// WithValidationMode returns an Option function that sets the pdfcpu validation mode
+// used while processing: "relaxed" (default), "strict" or "quiet".
+func WithValidationMode(mode string) Option {
+	return func(p *PDFProcessor) {
+		p.validationMode = mode
+	}
+}
+
+// WithDespeckle returns an Option function that removes scanner noise from images
+// with a 3x3 median filter before converting them to PDF.
+func WithDespeckle() Option {
+	return WithDespeckleKernel(defaultDespeckleSize)
+}
+
+// WithDespeckleKernel returns an Option function that removes scanner noise from images
+// with a size x size median filter before converting them to PDF. The size must be odd.
+func WithDespeckleKernel(size int) Option {
+	return func(p *PDFProcessor) {
+		p.imageConversion.DespeckleSize = size
+	}
+}
+
+// WithSourceFilename returns an Option function that records the base name of the input file
+// as the SourceFilename custom property of the output, so it survives renames.
+func WithSourceFilename() Option {
+	return func(p *PDFProcessor) {
+		p.recordSource = true
+	}
+}
+
+// WithJobID returns an Option function that records a correlation or job ID
+// as the JobID custom property of the output.
+func WithJobID(id string) Option {
+	return func(p *PDFProcessor) {
+		p.jobID = id
+	}
+}
+
+// WithOrientationStamps returns an Option function that stamps portrait and landscape pages
+// with separate stamp specs, chosen per page from its media box.
+// Empty spec fields fall back to QRCodePath, StampPosition, StampWidthRatio, StampScale,
+// StampRotation, StampOpacity and StampOffsetX/StampOffsetY.
+func WithOrientationStamps(portrait StampSpec, landscape StampSpec) Option {
+	return func(p *PDFProcessor) {
+		p.portraitStamp = &portrait
+		p.landscapeStamp = &landscape
+	}
+}
+
+// WithStamps returns an Option function that applies the stamp specs in order instead of the
+// single QR code, each on its own Pages, e.g. one QR code at the top left of odd pages and
+// another at the bottom right of even pages. Empty spec fields fall back like those of
+// WithOrientationStamps, and Pages to StampPages. Every spec is validated before processing.
+func WithStamps(specs ...StampSpec) Option {
+	return func(p *PDFProcessor) {
+		p.stamps = specs
+	}
+}
+
+// withStampDefaults fills the empty fields of spec from the file options.
+func (p *PDFProcessor) withStampDefaults(spec StampSpec) StampSpec {
+	if spec.ImagePath == "" {
+		spec.ImagePath = p.QRCodePath
+	}
+	if spec.Position == "" {
+		spec.Position = p.StampPosition
+	}
+	if spec.WidthRatio == 0 {
+		spec.WidthRatio = p.StampWidthRatio
+	}
+	if spec.Scale == 0 {
+		spec.Scale = p.StampScale
+	}
+	if spec.Rotation == 0 {
+		spec.Rotation = p.StampRotation
+	}
+	if spec.Opacity == 0 {
+		spec.Opacity = p.StampOpacity
+	}
+	if spec.OffsetX == 0 && spec.OffsetY == 0 {
+		spec.OffsetX, spec.OffsetY = p.StampOffsetX, p.StampOffsetY
+	}
+	if spec.AltText == "" {
+		spec.AltText = p.StampAltText
+	}
+	if spec.Pages == "" {
+		spec.Pages = p.StampPages
+	}
+	if spec.extraFlags == nil {
+		spec.extraFlags = p.extraStampFlags
+	}
+
+	return spec
+}
+
+// WithGeneratedQR returns an Option function that generates a QR code encoding data, with the icon
+// at iconPath in its center unless iconPath is empty, and stamps it instead of the QR code at QRCodePath.
+// The generated image is written to a temporary file that is removed after processing.
+func WithGeneratedQR(data string, iconPath string) Option {
+	return func(p *PDFProcessor) {
+		p.qrData = data
+		p.qrIconPath = iconPath
+	}
+}
+
+// WithPDFCompression returns an Option function that enables or disables stream compression
+// of PDF files converted from images. Compression is enabled by default.
+func WithPDFCompression(enabled bool) Option {
+	return func(p *PDFProcessor) {
+		p.imageConversion.DisableCompression = !enabled
+	}
+}
+
+// WithImageDPI returns an Option function that places an image converted to PDF at its size
+// when printed at dpi pixels per inch, instead of spanning the page width. Small images such as
+// receipts keep their sharpness, images that don't fit the page are shrunk to fit and downsampled
+// to dpi to keep the file size down.
+func WithImageDPI(dpi int) Option {
+	return func(p *PDFProcessor) {
+		p.imageConversion.ImageDPI = dpi
+	}
+}
+
+// WithJPEGQuality returns an Option function that re-encodes JPEG images converted to PDF at the
+// given quality from 1 to 100, trading image quality for a smaller output file.
+func WithJPEGQuality(quality int) Option {
+	return func(p *PDFProcessor) {
+		p.imageConversion.JPEGQuality = quality
+	}
+}
+
+// pageSizeNames lists the page sizes accepted by WithPageSize.
+var pageSizeNames = []string{"A3", "A4", "A5", "Letter", "Legal"}
+
+// WithPageSize returns an Option function that sets the page size, such as "A4", "Letter" or "Legal",
+// and the orientation, "P" or "L", of PDF files converted from images. The orientation "auto" picks
+// landscape for images wider than they are tall. The default is A4 portrait.
+func WithPageSize(size string, orientation string) Option {
+	return func(p *PDFProcessor) {
+		p.imageConversion.PageSize = size
+		p.imageConversion.Orientation = orientation
+	}
+}
+
+// pageLayout returns the gofpdf page size and orientation for an image with the given bounds.
+func (c imageConversion) pageLayout(bounds image.Rectangle) (string, string, error) {
+	size := "A4"
+	if c.PageSize != "" {
+		size = ""
+		for _, name := range pageSizeNames {
+			if strings.EqualFold(c.PageSize, name) {
+				size = name
+			}
+		}
+
+		if size == "" {
+			return "", "", fmt.Errorf("invalid page size: %s, expected one of %s", c.PageSize, strings.Join(pageSizeNames, ", "))
+		}
+	}
+
+	switch c.Orientation {
+	case "", "P":
+		return size, "P", nil
+	case "L":
+		return size, "L", nil
+	case "auto":
+		if bounds.Dx() > bounds.Dy() {
+			return size, "L", nil
+		}
+		return size, "P", nil
+	default:
+		return "", "", fmt.Errorf("invalid page orientation: %s", c.Orientation)
+	}
+}
+
+// WithExistingStamps returns an Option function that sets how stamps already present in the PDF are handled.
+// The default StampLayer adds the new stamp on top of them.
+func WithExistingStamps(policy StampPolicy) Option {
+	return func(p *PDFProcessor) {
+		p.existingStamps = policy
+	}
+}
+
+// WithWritePolicy returns an Option function that sets whether a PDF input file is processed in place
+// or as a copy. The default CopyOnWrite leaves the input untouched, OutputPath points to the copy.
+// Images and documents are always converted to a new PDF file first.
+func WithWritePolicy(policy WritePolicy) Option {
+	return func(p *PDFProcessor) {
+		p.writePolicy = policy
+	}
+}
+
+// WithOutputPath returns an Option function that writes the processed PDF file to path, which
+// Close leaves in place. It takes precedence over the write policy, so the input is preserved,
+// and applies to images and documents as well. An existing file at path is only replaced
+// with WithOverwrite.
+func WithOutputPath(path string) Option {
+	return func(p *PDFProcessor) {
+		p.outputPath = path
+	}
+}
+
+// WithOverwrite returns an Option function that sets whether an existing file at the path of
+// WithOutputPath is replaced, processing fails with an error wrapping os.ErrExist otherwise.
+func WithOverwrite(overwrite bool) Option {
+	return func(p *PDFProcessor) {
+		p.overwrite = overwrite
+	}
+}
+
+// WithTimestampFooter returns an Option function that stamps a footer line at the bottom center
+// of every page with the processing date and time in UTC, followed by the job ID when set.
+func WithTimestampFooter() Option {
+	return func(p *PDFProcessor) {
+		p.timestampFooter = true
+	}
+}
+
+// WithTextStamp returns an Option function that stamps text, such as "CONFIDENTIAL", as a
+// watermark at the pdfcpu anchor pos, the center when empty. It is stamped on the pages selected
+// by StampPages together with the QR code, without a QR code only the text is stamped.
+func WithTextStamp(text string, pos string) Option {
+	return func(p *PDFProcessor) {
+		p.textStamp = text
+		p.textStampPosition = StampPosition(pos)
+	}
+}
+
+// WithConversionTimeout returns an Option function that limits the time LibreOffice may take to
+// convert a document. On timeout soffice and every process it started are killed.
+// There is no limit by default.
+func WithConversionTimeout(timeout time.Duration) Option {
+	return func(p *PDFProcessor) {
+		p.conversionTimeout = timeout
+	}
+}
+
+// WithExtraStampFlags returns an Option function that passes additional flags to the pdfcpu
+// stamp commands of the QR code, for pdfcpu features without a typed option.
+// Every flag must start with a dash and attach its value with "=", e.g. "--unit=mm".
+func WithExtraStampFlags(flags []string) Option {
+	return func(p *PDFProcessor) {
+		p.extraStampFlags = flags
+	}
+}
+
+// WithExtraEncryptFlags returns an Option function that passes additional flags to the pdfcpu
+// encrypt command, e.g. "--key=128". Flags follow the rules of WithExtraStampFlags.
+func WithExtraEncryptFlags(flags []string) Option {
+	return func(p *PDFProcessor) {
+		p.extraEncryptFlags = flags
+	}
+}
+
+// WithExtraValidateFlags returns an Option function that passes additional flags to the pdfcpu
+// validate command of the password check. Flags follow the rules of WithExtraStampFlags.
+func WithExtraValidateFlags(flags []string) Option {
+	return func(p *PDFProcessor) {
+		p.extraValidateFlags = flags
+	}
+}
+
+// WithEncryptWhen returns an Option function that encrypts the output of an unprotected input
+// with PasswordPDF when match reports true for the text of the document, e.g.
+// regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`).MatchString for SSN-like tokens.
+// PDFProtection reports whether the output was encrypted. The text is extracted like PDFToTextFile.
+func WithEncryptWhen(match func(text string) bool) Option {
+	return func(p *PDFProcessor) {
+		p.encryptWhen = match
+	}
+}
+
+// WithTempDir returns an Option function that creates every intermediate file in dir, os.TempDir()
+// when empty, so processing needs no write access to the input location. The PDF files converted
+// from images and documents and the copy of CopyOnWrite, which Close removes, are written there
+// too instead of next to the input. InPlace still replaces the input file.
+func WithTempDir(dir string) Option {
+	return func(p *PDFProcessor) {
+		if dir == "" {
+			dir = os.TempDir()
+		}
+		p.tempDir = dir
+	}
+}
+
+// WithPDFCPUConfigDir returns an Option function that runs pdfcpu with its configuration in dir
+// instead of the user configuration directory. pdfcpu creates the configuration when missing.
+// By default every run of ProcessFile uses a temporary configuration that is removed afterwards.
+func WithPDFCPUConfigDir(dir string) Option {
+	return func(p *PDFProcessor) {
+		p.pdfcpuConfigDir = dir
+	}
+}
+
 // ProcessFile processes the input file based on its type.
+// OutputPath is set to the processed PDF file, which WriteTo streams.
 func (p *PDFProcessor) ProcessFile() error {
+	return p.ProcessFileContext(context.Background())
+}
+
+// ProcessFileContext processes the input file like ProcessFile. The pdfcpu and soffice processes
+// are killed when ctx is done, in which case ctx.Err() is returned.
+func (p *PDFProcessor) ProcessFileContext(ctx context.Context) error {
+	configDir := p.pdfcpuConfigDir
+	if configDir == "" {
+		dir, err := os.MkdirTemp(p.tempDir, "pdfcpu-config-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+
+		configDir = dir
+	}
+
+	runCtx := withPDFCPUConfigDir(ctx, configDir)
+	if p.dryRun != nil {
+		runCtx = context.WithValue(runCtx, dryRunKey{}, p.dryRun)
+	}
+
+	err := ctx.Err()
+	if err == nil {
+		err = p.processFile(runCtx)
+	}
+
+	// Report the cancellation rather than the error of the killed process
+	if err != nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+
+	p.ProcessError = ""
+	if err != nil {
+		p.ProcessError = err.Error()
+	}
+
+	return err
+}
+
+// processFile processes the input file based on its type.
+func (p *PDFProcessor) processFile(ctx context.Context) error {
+	// Reject invalid stamp options before any file is written
+	err := validateStampSpec(p.withStampDefaults(StampSpec{}))
+	if err != nil {
+		return err
+	}
+
+	for _, spec := range []*StampSpec{p.portraitStamp, p.landscapeStamp} {
+		if spec != nil {
+			err = validateStampSpec(p.withStampDefaults(*spec))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for i, spec := range p.stamps {
+		spec = p.withStampDefaults(spec)
+		err = validateStampSpec(spec)
+		if err == nil {
+			_, err = parsePageSelection(spec.Pages, 0)
+		}
+		if err != nil {
+			return fmt.Errorf("stamp %d: %w", i+1, err)
+		}
+	}
+
+	// Don't do any work that would be thrown away
+	if p.outputPath != "" && !p.overwrite {
+		_, err = os.Stat(p.outputPath)
+		if err == nil {
+			return fmt.Errorf("%w: %s", os.ErrExist, p.outputPath)
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	// The text watermark is centered without a position
+	if p.textStampPosition != "" {
+		err = validateStampPosition(p.textStampPosition)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = validateRotation(p.rotateAngle, p.rotatePages)
+	if err != nil {
+		return err
+	}
+
+	// Only the selector syntax can be checked before the page count is known
+	_, err = parsePageSelection(p.StampPages, 0)
+	if err != nil {
+		return err
+	}
+
+	if p.forceEncryption && p.encryptionPassword == "" {
+		return fmt.Errorf("%w: encryption requires a user password", ErrMissingPassword)
+	}
+
+	if p.permissions != nil {
+		err = validatePermissions(p.userPassword(), p.OwnerPasswordPDF)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, flags := range [][]string{p.extraStampFlags, p.extraEncryptFlags, p.extraValidateFlags} {
+		err = validateExtraFlags(flags)
+		if err != nil {
+			return err
+		}
+	}
+
 	fileType := getFileType(p.FilePath)
+	if p.checkFileType && fileType != "" {
+		sniffed := sniffFile(p.FilePath)
+		if sniffed != fileType {
+			if sniffed == "" {
+				sniffed = "unsupported"
+			}
+			return fmt.Errorf("file content doesn't match its extension: %s content in %s", sniffed, filepath.Base(p.FilePath))
+		}
+	}
+
+	if len(p.mergedImages) > 0 && fileType != Image {
+		return fmt.Errorf("merged images require an image input file, got %s", filepath.Base(p.FilePath))
+	}
+
+	// Fail before any work when a binary needed for the file type is missing
+	if p.checkDependencies && fileType != "" {
+		dependencies := []dependency{pdfcpuDependency}
+		if fileType == Document {
+			dependencies = append(dependencies, sofficeDependency)
+		}
+
+		err = checkDependencies(true, dependencies...)
+		if err != nil {
+			return err
+		}
+	}
+
 	switch fileType {
 	case PDF:
 		// Check if the PDF file has a password
-		hasPassword, err := hasPDFPassword(p.FilePath, p.PasswordPDF)
+		hasPassword, err := hasPDFPassword(ctx, p.FilePath, p.validationMode, p.extraValidateFlags...)
 		if err != nil {
 			return err
 		}
 
 		p.PDFProtection = hasPassword
 
+		// Resolve where the processed file goes according to the write policy
+		destination, created, err := p.outputDestination()
+		if err != nil {
+			return err
+		}
+
+		// A new destination is created empty, remove it when processing fails
+		if created {
+			defer func() {
+				if p.OutputPath != destination {
+					os.Remove(destination)
+				}
+			}()
+		}
+
+		// Work on a temporary copy, the original is never decrypted or changed on disk
+		// and the destination is only replaced once the whole pipeline succeeded
+		filePath, err := temporaryCopy(p.FilePath, filepath.Dir(destination))
+		if err != nil {
+			return err
+		}
+		defer os.Remove(filePath)
+
 		if hasPassword {
+			if p.PasswordPDF == "" {
+				return fmt.Errorf("%w: %s is password protected", ErrMissingPassword, filepath.Base(p.FilePath))
+			}
+
 			// Descrypt the PDF File
-			err := decrypted(p.FilePath, p.PasswordPDF)
+			err := decrypted(ctx, filePath, p.PasswordPDF)
 			if err != nil {
 				return err
 			}
 		}
 
 		// Process the PDF file
-		err = p.processPDF(p.FilePath, p.OptionFilePDF.QRCodePath, p.OptionFilePDF.StampPosition)
+		err = p.processPDF(ctx, filePath, p.OptionFilePDF.QRCodePath, p.OptionFilePDF.StampPosition)
 		if err != nil {
 			return err
 		}
 
-		// // Delete the temporary PDF file
-		// err = os.Remove(filePath)
-		// if err != nil {
-		// 	return "", err
-		// }
-	case Image:
-		// Convert the image file to PDF
-		pdfFilePath, err := convertImageToPDF(p.FilePath)
+		// Keep the permissions of the input file, temporary files are only readable by the owner
+		info, err := os.Stat(p.FilePath)
 		if err != nil {
 			return err
 		}
 
-		// Process the converted PDF file
-		err = p.processPDF(pdfFilePath, p.OptionFilePDF.QRCodePath, p.OptionFilePDF.StampPosition)
+		err = os.Chmod(filePath, info.Mode().Perm())
 		if err != nil {
 			return err
 		}
 
-		// // Delete the temporary PDF file
-		// err = os.Remove(pdfFilePath)
-		// if err != nil {
-		// 	return "", err
-		// }
-	case Document:
-		// Convert the document file to PDF
-		pdfFilePath, err := convertDocumentToPDF(p.FilePath)
+		// Move the processed file into place, the temporary copy sits in the same directory
+		// so the rename is atomic
+		err = os.Rename(filePath, destination)
 		if err != nil {
 			return err
 		}
 
-		// Process the converted PDF file
-		err = p.processPDF(pdfFilePath, p.OptionFilePDF.QRCodePath, p.OptionFilePDF.StampPosition)
-		if err != nil {
-			return err
-		}
+		if created {
+			p.trackTempFile(destination)
+		}
+
+		p.OutputPath = destination
+	case Image:
+		// Convert the image file to PDF
+		pdfFilePath, err := p.convertCached(func() (string, error) {
+			return imageConverter(p.FilePath, p.tempDir, p.imageConversion)
+		})
+		if err != nil {
+			return err
+		}
+		p.trackTempFile(pdfFilePath)
+
+		// Append the other images, the combined PDF is stamped once
+		if len(p.mergedImages) > 0 {
+			err = p.appendMergedImages(ctx, pdfFilePath)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Process the converted PDF file
+		err = p.processPDF(ctx, pdfFilePath, p.OptionFilePDF.QRCodePath, p.OptionFilePDF.StampPosition)
+		if err != nil {
+			return err
+		}
+
+		err = p.moveToOutputPath(pdfFilePath)
+		if err != nil {
+			return err
+		}
+	case Document:
+		// Convert the document file to PDF
+		pdfFilePath, err := p.convertCached(func() (string, error) {
+			return p.convertDocument(ctx)
+		})
+		if err != nil {
+			return err
+		}
+		p.trackTempFile(pdfFilePath)
+
+		// Process the converted PDF file
+		err = p.processPDF(ctx, pdfFilePath, p.OptionFilePDF.QRCodePath, p.OptionFilePDF.StampPosition)
+		if err != nil {
+			return err
+		}
+
+		err = p.moveToOutputPath(pdfFilePath)
+		if err != nil {
+			return err
+		}
+	default:
+		return unsupportedFileTypeError(p.FilePath)
+	}
+
+	return nil
+}
+
+// unsupportedFileTypeError returns ErrUnsupportedFileType wrapped with the extension of filePath.
+func unsupportedFileTypeError(filePath string) error {
+	extension := filepath.Ext(filePath)
+	if extension == "" {
+		return fmt.Errorf("%w: %s has no extension", ErrUnsupportedFileType, filepath.Base(filePath))
+	}
+
+	return fmt.Errorf("%w: %s", ErrUnsupportedFileType, extension)
+}
+
+// outputDestination returns the path the processed PDF file is written to according to the
+// output path and the write policy, and whether it was created empty for the copy.
+func (p *PDFProcessor) outputDestination() (string, bool, error) {
+	if p.outputPath != "" {
+		return p.outputPath, false, nil
+	}
+
+	switch p.writePolicy {
+	case InPlace:
+		return p.FilePath, false, nil
+	case CopyOnWrite:
+		destination, err := createProcessedFile(p.FilePath, p.tempDir)
+		return destination, err == nil, err
+	default:
+		return "", false, fmt.Errorf("invalid write policy: %s", p.writePolicy)
+	}
+}
+
+// moveToOutputPath moves the PDF file converted from an image or document to the path of
+// WithOutputPath, if set.
+func (p *PDFProcessor) moveToOutputPath(pdfFilePath string) error {
+	if p.outputPath == "" {
+		return nil
+	}
+
+	// The conversion may sit on another file system, fall back to copying
+	err := os.Rename(pdfFilePath, p.outputPath)
+	if err != nil {
+		err = copyFile(pdfFilePath, p.outputPath)
+		if err != nil {
+			return err
+		}
+		os.Remove(pdfFilePath)
+	}
+
+	p.OutputPath = p.outputPath
+
+	return nil
+}
+
+// temporaryCopy copies filePath to a new hidden temporary file in dir and returns its path.
+func temporaryCopy(filePath string, dir string) (string, error) {
+	tempFile, err := os.CreateTemp(dir, ".pdfgopher-*.pdf")
+	if err != nil {
+		return "", err
+	}
+	tempFile.Close()
+
+	err = copyFile(filePath, tempFile.Name())
+	if err != nil {
+		os.Remove(tempFile.Name())
+		return "", err
+	}
+
+	return tempFile.Name(), nil
+}
+
+// pdfToBase64 converts a PDF file to base64 encoding.
+func (p *PDFProcessor) pdfToBase64(filePath string) error {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+
+	// Encode the PDF file as base64.
+	var builder strings.Builder
+	builder.Grow(base64.StdEncoding.EncodedLen(int(info.Size())))
+
+	err = writeBase64(&builder, filePath)
+	if err != nil {
+		return err
+	}
+
+	// Set the Base64Output field of the PDFProcessor struct.
+	p.Base64Output = builder.String()
+
+	return nil
+}
+
+// writeBase64 writes the base64 encoding of the file to w. The file is streamed through the
+// encoder instead of read into memory.
+func writeBase64(w io.Writer, filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := base64.NewEncoder(base64.StdEncoding, w)
+	_, err = io.Copy(encoder, file)
+	if err != nil {
+		return err
+	}
+
+	// Flush the final partial block
+	return encoder.Close()
+}
+
+// OutputSize returns the size in bytes of the processed PDF file.
+func (p *PDFProcessor) OutputSize() (int64, error) {
+	if p.OutputPath == "" {
+		return 0, errors.New("file has not been processed")
+	}
+
+	info, err := os.Stat(p.OutputPath)
+	if err != nil {
+		return 0, err
+	}
+
+	return info.Size(), nil
+}
+
+// WriteTo streams the processed PDF file to w. It implements io.WriterTo.
+func (p *PDFProcessor) WriteTo(w io.Writer) (int64, error) {
+	if p.OutputPath == "" {
+		return 0, errors.New("file has not been processed")
+	}
+
+	file, err := os.Open(p.OutputPath)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	return io.Copy(w, file)
+}
+
+// Base64To streams the base64 encoding of the processed PDF file to w, such as an HTTP response,
+// without holding the encoded file in memory. Combine it with WithoutBase64 for large files.
+func (p *PDFProcessor) Base64To(w io.Writer) error {
+	if p.OutputPath == "" {
+		return errors.New("file has not been processed")
+	}
+
+	return writeBase64(w, p.OutputPath)
+}
+
+// Close removes the files the processor created: the processed file unless it was processed
+// in place, image and document conversions and the buffered input of NewPDFGopherFromReader.
+// OutputPath is cleared, so call Close once done with the output.
+func (p *PDFProcessor) Close() error {
+	var errs []error
+	for _, filePath := range p.tempFiles {
+		err := os.Remove(filePath)
+		if err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+		}
+	}
+
+	p.tempFiles = nil
+	p.OutputPath = ""
+
+	return errors.Join(errs...)
+}
+
+// Reset clears the results of the previous run and sets FilePath to newPath, keeping the options,
+// so one configured processor can process several files in turn. The files created by earlier
+// runs are still removed by Close.
+func (p *PDFProcessor) Reset(newPath string) {
+	p.FilePath = newPath
+	p.OutputPath = ""
+	p.Base64Output = ""
+	p.PDFProtection = false
+	p.PageCount = 0
+	p.ProcessError = ""
+	p.SizeBefore = 0
+	p.SizeAfter = 0
+}
+
+// trackTempFile records filePath to be removed by Close.
+func (p *PDFProcessor) trackTempFile(filePath string) {
+	for _, tracked := range p.tempFiles {
+		if tracked == filePath {
+			return
+		}
+	}
+
+	p.tempFiles = append(p.tempFiles, filePath)
+}
+
+// Base64Chunks splits Base64Output into chunks of at most size characters for chunked transport.
+// It returns nil when size is not positive. Use JoinBase64Chunks to reassemble the chunks.
+func (p *PDFProcessor) Base64Chunks(size int) []string {
+	if size <= 0 {
+		return nil
+	}
+
+	chunks := make([]string, 0, (len(p.Base64Output)+size-1)/size)
+	for start := 0; start < len(p.Base64Output); start += size {
+		end := start + size
+		if end > len(p.Base64Output) {
+			end = len(p.Base64Output)
+		}
+
+		chunks = append(chunks, p.Base64Output[start:end])
+	}
+
+	return chunks
+}
+
+// JoinBase64Chunks reassembles the chunks returned by Base64Chunks into the original base64 output.
+func JoinBase64Chunks(chunks []string) string {
+	return strings.Join(chunks, "")
+}
+
+// fileTypeExtensions maps the supported file extensions to their file type.
+// Documents are converted by LibreOffice, which handles any of its formats the same way.
+var fileTypeExtensions = map[string]FileType{
+	".pdf":  PDF,
+	".jpg":  Image,
+	".jpeg": Image,
+	".png":  Image,
+	".webp": Image,
+	".tif":  Image,
+	".tiff": Image,
+	".doc":  Document,
+	".docx": Document,
+	".odt":  Document,
+	".rtf":  Document,
+	".xlsx": Document,
+	".pptx": Document,
+}
+
+// SupportedExtensions returns the lowercase file extensions that can be processed, sorted,
+// e.g. to validate uploads before processing them.
+func SupportedExtensions() []string {
+	extensions := make([]string, 0, len(fileTypeExtensions))
+	for extension := range fileTypeExtensions {
+		extensions = append(extensions, extension)
+	}
+	sort.Strings(extensions)
+
+	return extensions
+}
+
+// getFileType returns the type of file based on its extension.
+// Files without a known extension, such as uploads, are recognized by their content.
+func getFileType(filePath string) FileType {
+	extension := strings.ToLower(filepath.Ext(filePath))
+	if fileType, ok := fileTypeExtensions[extension]; ok {
+		return fileType
+	}
+
+	return sniffFile(filePath)
+}
+
+// validationFlags returns the pdfcpu validate flags for the given validation mode.
+func validationFlags(mode string) (string, error) {
+	switch mode {
+	case "relaxed":
+		return "--mode relaxed", nil
+	case "strict":
+		return "--mode strict", nil
+	case "quiet":
+		return "--mode relaxed --quiet", nil
+	default:
+		return "", fmt.Errorf("invalid validation mode: %s", mode)
+	}
+}
+
+// passwordPrompt is the message pdfcpu prints when a file can't be opened without a password.
+const passwordPrompt = "please provide the correct password"
+
+// hasPDFPassword checks if the PDF file is password-protected.
+// The file is validated without a password, so a protected file fails validation asking for
+// the password. Any other validation failure, such as a corrupt file, is returned as an error.
+// The extra flags are passed to pdfcpu validate as well.
+func hasPDFPassword(ctx context.Context, filePath string, validationMode string, extraFlags ...string) (bool, error) {
+	flags, err := validationFlags(validationMode)
+	if err != nil {
+		return false, err
+	}
+
+	args := append(append(append([]string{"validate"}, strings.Fields(flags)...), extraFlags...), filePath)
+
+	_, err = runPDFCPU(ctx, args...)
+	if err != nil {
+		var pdfcpuErr *PDFCPUError
+		if exitCode(err) == 1 && errors.As(err, &pdfcpuErr) && strings.Contains(pdfcpuErr.Stderr, passwordPrompt) {
+			// PDF is password protected
+			return true, nil
+		} else {
+			// Corrupt file or other execution error
+			return false, err
+		}
+	} else {
+		// PDF is not password protected
+		return false, err
+	}
+}
+
+// pdfcpuInfo represents the part of the pdfcpu info JSON output used by this package.
+type pdfcpuInfo struct {
+	PageCount        int               `json:"pageCount"`
+	Version          string            `json:"version"`
+	Encrypted        bool              `json:"encrypted"`
+	Title            string            `json:"title"`
+	Author           string            `json:"author"`
+	Subject          string            `json:"subject"`
+	Keywords         []string          `json:"keywords"`
+	Creator          string            `json:"creator"`
+	Producer         string            `json:"producer"`
+	CreationDate     string            `json:"creationDate"`
+	ModificationDate string            `json:"modificationDate"`
+	Properties       map[string]string `json:"properties"`
+	Watermarked      bool              `json:"watermarked"`
+	PageBoundaries   map[string]struct {
+		MediaBox pdfcpuBox  `json:"mediaBox"`
+		CropBox  *pdfcpuBox `json:"cropBox"`
+		Rotation int        `json:"rot"`
+	} `json:"pageBoundaries"`
+}
+
+// pdfcpuBox represents a page boundary of the pdfcpu info JSON output.
+type pdfcpuBox struct {
+	Rect struct {
+		LL struct{ X, Y float64 } `json:"ll"`
+		UR struct{ X, Y float64 } `json:"ur"`
+	} `json:"rect"`
+}
+
+// pageSize represents the media box dimensions of a page in points.
+type pageSize struct {
+	Width  float64
+	Height float64
+}
+
+// readPDFInfo reads the info of the PDF file using pdfcpu-cli.
+// When pages is not empty the page boundaries of the selected pages are included.
+// The flags, such as a password, are passed to pdfcpu info as well.
+func readPDFInfo(ctx context.Context, filePath string, pages string, flags ...string) (*pdfcpuInfo, error) {
+	args := []string{"info", "--json"}
+	if pages != "" {
+		args = append(args, "--pages", pages)
+	}
+	args = append(append(args, flags...), filePath)
+
+	output, err := runPDFCPU(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var info struct {
+		Infos []pdfcpuInfo `json:"infos"`
+	}
+
+	err = json.Unmarshal(output, &info)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(info.Infos) == 0 {
+		return nil, fmt.Errorf("no info found for file: %s", filePath)
+	}
+
+	return &info.Infos[0], nil
+}
+
+// pageCount returns the number of pages of the PDF file using pdfcpu-cli.
+func pageCount(ctx context.Context, filePath string) (int, error) {
+	info, err := readPDFInfo(ctx, filePath, "")
+	if err != nil {
+		return 0, err
+	}
+
+	return info.PageCount, nil
+}
+
+// pageSizes returns the media box size of every page of the PDF file, indexed by page number - 1.
+func pageSizes(ctx context.Context, filePath string) ([]pageSize, error) {
+	mediaBoxes, err := pageMediaBoxes(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	sizes := make([]pageSize, len(mediaBoxes))
+	for i, mediaBox := range mediaBoxes {
+		sizes[i] = pageSize{Width: mediaBox.URX - mediaBox.LLX, Height: mediaBox.URY - mediaBox.LLY}
+	}
+
+	return sizes, nil
+}
+
+// pageMediaBoxes returns the media box of every page of the PDF file, whose lower left corner
+// isn't necessarily the origin.
+func pageMediaBoxes(ctx context.Context, filePath string) ([]Rect, error) {
+	info, err := readPDFInfo(ctx, filePath, "1-")
+	if err != nil {
+		return nil, err
+	}
+
+	mediaBoxes := make([]Rect, info.PageCount)
+	for page, boundaries := range info.PageBoundaries {
+		number, err := strconv.Atoi(page)
+		if err != nil || number < 1 || number > info.PageCount {
+			return nil, fmt.Errorf("invalid page number in pdfcpu info: %s", page)
+		}
+
+		rect := boundaries.MediaBox.Rect
+		mediaBoxes[number-1] = Rect{LLX: rect.LL.X, LLY: rect.LL.Y, URX: rect.UR.X, URY: rect.UR.Y}
+	}
+
+	return mediaBoxes, nil
+}
+
+// verifyPageCount checks that the PDF file still has the expected number of pages.
+func verifyPageCount(ctx context.Context, filePath string, expected int) error {
+	count, err := pageCount(ctx, filePath)
+	if err != nil {
+		return err
+	}
+
+	if count != expected {
+		return fmt.Errorf("%w: expected %d pages, got %d", ErrPageCountChanged, expected, count)
+	}
+
+	return nil
+}
+
+// decrypted unction is used to remove the protection from a PDF file by decrypting it with a provided password.
+func decrypted(ctx context.Context, filePath string, password string) error {
+	_, err := runPDFCPU(ctx, "decrypt", "--upw", password, filePath)
+	return err
+}
+
+// DecryptToFile writes a decrypted copy of the protected PDF input file to output, without
+// stamping or changing it otherwise. It returns ErrWrongPassword when the password doesn't open it.
+// An unprotected input is copied as is. input and output may be the same file.
+func DecryptToFile(input string, password string, output string) error {
+	ctx := context.Background()
+
+	protected, err := hasPDFPassword(ctx, input, "relaxed")
+	if err != nil {
+		return err
+	}
+
+	if !protected {
+		if sameFile(input, output) {
+			return nil
+		}
+
+		return stageFile(output, func(tempPath string) error {
+			return copyFile(input, tempPath)
+		})
+	}
+
+	// Validate the password first, decrypt doesn't tell a wrong password from a broken file
+	_, err = runPDFCPU(ctx, "validate", "--mode", "relaxed", "--upw", password, input)
+	if err != nil {
+		if exitCode(err) == 1 {
+			return ErrWrongPassword
+		}
+		return err
+	}
+
+	return stageFile(output, func(tempPath string) error {
+		err := copyFile(input, tempPath)
+		if err != nil {
+			return err
+		}
+
+		return decrypted(ctx, tempPath, password)
+	})
+}
+
+// encrypted function is used to encrypt a previously decrypted PDF.
+// An empty owner password defaults to the user password. The extra flags are passed
+// to pdfcpu encrypt as well.
+func encrypted(ctx context.Context, filePath string, userPassword string, ownerPassword string, extraFlags ...string) error {
+	if ownerPassword == "" {
+		ownerPassword = userPassword
+	}
+
+	args := append(append([]string{"encrypt", "--upw", userPassword, "--opw", ownerPassword}, extraFlags...), filePath)
+
+	_, err := runPDFCPU(ctx, args...)
+	return err
+}
+
+// processPDF performs operations on the PDF file using pdfcpu-cli.
+func (p *PDFProcessor) processPDF(ctx context.Context, filePath string, qrCode string, stampPosition StampPosition) error {
+	// Generate the QR code on the fly
+	if p.qrData != "" {
+		qrFile, err := os.CreateTemp(p.tempDir, "qr-*.png")
+		if err != nil {
+			return err
+		}
+		qrFile.Close()
+		defer os.Remove(qrFile.Name())
+
+		if p.qrIconPath == "" {
+			qrCode, err = GenerateQRCode(p.qrData, qrFile.Name())
+		} else {
+			qrCode, err = GenerateQRCodeWithIcon(p.qrData, p.qrIconPath, qrFile.Name())
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// Remember the page count to verify stamping didn't drop or duplicate pages
+	pages, err := pageCount(ctx, filePath)
+	if err != nil {
+		return err
+	}
+
+	// Decide on encryption from the document's own text, before stamps are added
+	if !p.PDFProtection && !p.forceEncryption && p.encryptWhen != nil {
+		text, err := documentText(ctx, filePath)
+		if err != nil {
+			return err
+		}
+
+		if p.encryptWhen(text) {
+			if p.OptionFilePDF.PasswordPDF == "" {
+				return fmt.Errorf("%w: document matches the encryption predicate but PasswordPDF is empty", ErrMissingPassword)
+			}
+			p.PDFProtection = true
+		}
+	}
+
+	// Turn sideways pages upright before they are stamped
+	if p.rotateAngle != 0 {
+		selected := make([]int, pages)
+		for i := range selected {
+			selected[i] = i + 1
+		}
+		if p.rotatePages != "" {
+			selected, err = parsePageSelection(p.rotatePages, pages)
+			if err != nil {
+				return err
+			}
+		}
+		if len(selected) == 0 {
+			return fmt.Errorf("no pages selected by %s", p.rotatePages)
+		}
+
+		err = rotatePages(ctx, filePath, p.rotateAngle, selected)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Don't stamp on top of form fields when asked not to
+	if p.rejectFormFields {
+		err = checkFormFields(ctx, filePath)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Handle stamps left by a previous run
+	stamp, err := p.prepareExistingStamps(ctx, filePath)
+	if err != nil {
+		return err
+	}
+
+	// Add QR code to the PDF file
+	switch {
+	case !stamp:
+		// Keep the existing stamps as they are
+	case len(p.stamps) > 0:
+		err = p.addStamps(ctx, filePath, pages)
+	case p.portraitStamp != nil && p.landscapeStamp != nil:
+		portrait, landscape := p.withStampDefaults(*p.portraitStamp), p.withStampDefaults(*p.landscapeStamp)
+		err = addOrientationStamps(ctx, filePath, portrait, landscape)
+	default:
+		var selected []int
+		selected, err = parsePageSelection(p.StampPages, pages)
+		if err == nil && len(selected) == 0 {
+			err = fmt.Errorf("no pages selected by %s", p.StampPages)
+		}
+		// Without a QR code only the text watermark is stamped
+		spec := p.withStampDefaults(StampSpec{ImagePath: qrCode, PDFPath: p.pdfStampPath, PDFPage: p.pdfStampPage, Position: stampPosition})
+		if err == nil && (spec.ImagePath != "" || spec.PDFPath != "" || p.textStamp == "") {
+			err = addImageStamp(ctx, filePath, spec, selected)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	// Add the text watermark on the pages selected for the QR code
+	if stamp && p.textStamp != "" {
+		selected, err := parsePageSelection(p.StampPages, pages)
+		if err != nil {
+			return err
+		}
+		if len(selected) == 0 {
+			return fmt.Errorf("no pages selected by %s", p.StampPages)
+		}
+
+		err = runStampCommand(ctx, textStampArgs(p.textStamp, p.textStampPosition, joinPages(selected), filePath)...)
+		if err != nil {
+			return err
+		}
+	}
+
+	//add timestamp footer to file pdf
+	if p.timestampFooter {
+		err := addTextStamp(ctx, filePath, p.footerText(time.Now()), BottomCenter)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = verifyPageCount(ctx, filePath, pages)
+	if err != nil {
+		return err
+	}
+	p.PageCount = pages
+
+	//add metadata to file pdf
+	if !IsStructEmpty(p.OptionMetadataPDF) {
+		err := addedMetadata(ctx, filePath, p.OptionMetadataPDF)
+		if err != nil {
+			return err
+		}
+	}
+
+	//add traceability properties to file pdf
+	properties := map[string]string{}
+	if p.recordSource {
+		properties["SourceFilename"] = filepath.Base(p.FilePath)
+	}
+	if p.jobID != "" {
+		properties["JobID"] = p.jobID
+	}
+
+	if len(properties) > 0 {
+		err := addProperties(ctx, filePath, properties)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Shrink the output, encrypted files can't be optimized without their passwords
+	if p.optimize {
+		p.SizeBefore, p.SizeAfter, err = optimizePDF(ctx, filePath)
+		if err != nil {
+			return err
+		}
+	}
+
+	//add protection to file pdf
+	if p.forceEncryption {
+		p.PDFProtection = true
+	}
+
+	if p.PDFProtection {
+		err := encrypted(ctx, filePath, p.userPassword(), p.OptionFilePDF.OwnerPasswordPDF, p.extraEncryptFlags...)
+		if err != nil {
+			return err
+		}
+
+		if p.permissions != nil {
+			err = setPermissions(ctx, filePath, p.userPassword(), p.OptionFilePDF.OwnerPasswordPDF, *p.permissions)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	p.OutputPath = filePath
+
+	//Convert pdf file to base64 as output file
+	if !p.skipBase64 {
+		err = p.pdfToBase64(filePath)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// footerText returns the timestamp footer line for a file processed at processedAt.
+func (p *PDFProcessor) footerText(processedAt time.Time) string {
+	text := "Processed " + processedAt.UTC().Format("2006-01-02 15:04:05") + " UTC"
+	if p.jobID != "" {
+		text += " | Job " + p.jobID
+	}
+
+	return text
+}
+
+// addTextStamp adds a line of text to every page of the PDF file using pdfcpu-cli.
+func addTextStamp(ctx context.Context, filePath string, text string, position StampPosition) error {
+	description := fmt.Sprintf("pos:%s, offset:0 10, scale:1 abs, points:8, rot:0, fillcolor:#000000", position)
+
+	return runStampCommand(ctx, "stamp", "add", "--mode", "text", "--", text, description, filePath)
+}
+
+// textStampArgs returns the arguments of a pdfcpu command stamping text as a watermark
+// at position on the selected pages of the PDF file, the center when position is empty.
+func textStampArgs(text string, position StampPosition, selection string, filePath string) []string {
+	if position == "" {
+		position = Center
+	}
+	description := fmt.Sprintf("pos:%s, rot:0, scale:0.5 rel, fillcolor:#808080, op:0.6", position)
+
+	return []string{"stamp", "add", "--pages", selection, "--mode", "text", "--", text, description, filePath}
+}
+
+// prepareExistingStamps applies the existing stamp policy to the PDF file
+// and reports whether the new stamp should be added.
+func (p *PDFProcessor) prepareExistingStamps(ctx context.Context, filePath string) (bool, error) {
+	switch p.existingStamps {
+	case StampLayer:
+		return true, nil
+	case StampPreserve, StampReplace:
+	default:
+		return false, fmt.Errorf("invalid existing stamp policy: %s", p.existingStamps)
+	}
+
+	info, err := readPDFInfo(ctx, filePath, "")
+	if err != nil {
+		return false, err
+	}
+
+	if !info.Watermarked {
+		return true, nil
+	}
+
+	if p.existingStamps == StampPreserve {
+		return false, nil
+	}
+
+	return true, removeStamps(ctx, filePath)
+}
+
+// removeStamps removes all stamps and watermarks from the PDF file using pdfcpu-cli.
+func removeStamps(ctx context.Context, filePath string) error {
+	_, err := runPDFCPU(ctx, "stamp", "remove", filePath)
+	return err
+}
+
+// addedMetadata to add metadata into a pdf file.
+// Empty fields are skipped so they don't overwrite the existing values.
+func addedMetadata(ctx context.Context, filePath string, metadata *OptionMetadataPDF) error {
+	var properties []string
+	for _, field := range []struct{ Name, Value string }{
+		{"Title", metadata.Title},
+		{"Author", metadata.Author},
+		{"Subject", metadata.Subject},
+		{"Creator", metadata.Creator},
+	} {
+		if field.Value != "" {
+			properties = append(properties, field.Name+" = "+field.Value)
+		}
+	}
+
+	if len(properties) > 0 {
+		_, err := runPDFCPU(ctx, append([]string{"properties", "add", filePath}, properties...)...)
+		if err != nil {
+			return err
+		}
+	}
+
+	// pdfcpu manages keywords separately from the other fields
+	keywords := splitKeywords(metadata.Keywords)
+	if len(keywords) > 0 {
+		// Replace the existing keywords, removing fails when there are none
+		info, err := readPDFInfo(ctx, filePath, "")
+		if err != nil {
+			return err
+		}
+
+		if len(info.Keywords) > 0 {
+			_, err = runPDFCPU(ctx, "keywords", "remove", filePath)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = runPDFCPU(ctx, append([]string{"keywords", "add", filePath}, keywords...)...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// splitKeywords splits a comma separated list of keywords, dropping empty entries.
+func splitKeywords(list string) []string {
+	var keywords []string
+	for _, keyword := range strings.Split(list, ",") {
+		keyword = strings.TrimSpace(keyword)
+		if keyword != "" {
+			keywords = append(keywords, keyword)
+		}
+	}
+
+	return keywords
+}
+
+// metadataFields lists the document information keys that can be cleared with ClearMetadataField.
+var metadataFields = []string{"Title", "Author", "Subject"}
+
+// ClearMetadataField removes a single metadata field such as "Author" from the PDF file in place,
+// leaving the other fields untouched.
+func ClearMetadataField(filePath string, field string) error {
+	known := false
+	for _, name := range metadataFields {
+		if field == name {
+			known = true
+			break
+		}
+	}
+
+	if !known {
+		return fmt.Errorf("unknown metadata field: %s, expected one of %s", field, strings.Join(metadataFields, ", "))
+	}
+
+	_, err := runPDFCPU(context.Background(), "properties", "remove", filePath, field)
+	return err
+}
+
+// addProperties adds custom properties into a pdf file.
+func addProperties(ctx context.Context, filePath string, properties map[string]string) error {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Pass the output file explicitly, otherwise a value ending in .pdf is taken for it
+	args := []string{"properties", "add", "--force", filePath, filePath}
+	for _, name := range names {
+		args = append(args, fmt.Sprintf("%s = %s", name, properties[name]))
+	}
+
+	_, err := runPDFCPU(ctx, args...)
+	return err
+}
+
+// addQRCodeToPDF adds a QR code to the PDF file using pdfcpu-cli.
+// When widthRatio is greater than zero the QR code width is sized relative to the width of each page.
+func addQRCodeToPDF(ctx context.Context, filePath string, qrCode string, stampPosition StampPosition, widthRatio float64) error {
+	return addImageStamp(ctx, filePath, StampSpec{ImagePath: qrCode, Position: stampPosition, WidthRatio: widthRatio}, nil)
+}
+
+// addImageStamp stamps the image of spec on the selected pages of the PDF file using pdfcpu-cli.
+// A nil pages selection stamps every page. The AltText of spec is attached to the new stamps.
+func addImageStamp(ctx context.Context, filePath string, spec StampSpec, pages []int) error {
+	if spec.AltText == "" {
+		return stampImage(ctx, filePath, spec, pages)
+	}
+
+	// The alt text is written by PDFGopher itself, a dry run only lists it after the stamp
+	if w, ok := ctx.Value(dryRunKey{}).(io.Writer); ok {
+		err := stampImage(ctx, filePath, spec, pages)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(w, "# add alt text %s to the new stamps of %s\n", shellQuote(spec.AltText), shellQuote(filePath))
+		return err
+	}
+
+	// Remember the existing content to only describe the new stamps
+	before, err := contentDigests(filePath)
+	if err != nil {
+		return err
+	}
+
+	err = stampImage(ctx, filePath, spec, pages)
+	if err != nil {
+		return err
+	}
+
+	return addStampAltText(filePath, spec.AltText, before)
+}
+
+// stampImage stamps the image or PDF page of spec on the selected pages of the PDF file using
+// pdfcpu-cli. A nil pages selection stamps every page.
+func stampImage(ctx context.Context, filePath string, spec StampSpec, pages []int) error {
+	if spec.ImagePath == "" && spec.PDFPath == "" {
+		return ErrMissingQRCode
+	}
+
+	err := validateStampSpec(spec)
+	if err != nil {
+		return err
+	}
+
+	source, err := openStampSource(ctx, spec)
+	if err != nil {
+		return err
+	}
+
+	selection := "even,odd"
+	if pages != nil {
+		selection = joinPages(pages)
+	}
+
+	if spec.MaxWidth != 0 || spec.MaxHeight != 0 {
+		return addFittedImageStamp(ctx, filePath, source, spec, selection)
+	}
+
+	if spec.WidthRatio > 0 {
+		return addRelativeQRCodeToPDF(ctx, filePath, source, spec, pages)
+	}
+
+	scale := spec.Scale
+	if scale == 0 {
+		scale = fixedStampScale
+	}
+
+	return runStampCommand(ctx, source.args(spec, selection, fmt.Sprintf("%.4f", scale), filePath)...)
+}
+
+// stampSource represents the image or PDF page a stamp is made of.
+type stampSource struct {
+	// Mode is the pdfcpu stamp mode, "image" or "pdf".
+	Mode string
+	// Operand names the source for pdfcpu, the image path or "path:page" of a PDF page.
+	Operand string
+	// Width and Height are the image size in pixels or the page size in points.
+	Width  float64
+	Height float64
+}
+
+// openStampSource returns the source of the stamp described by spec. The PDF page takes
+// precedence over the image.
+func openStampSource(ctx context.Context, spec StampSpec) (stampSource, error) {
+	if spec.PDFPath != "" {
+		return openPDFStampSource(ctx, spec.PDFPath, spec.PDFPage)
+	}
+
+	// Load the icon image
+	iconFile, err := os.Open(spec.ImagePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return stampSource{}, fmt.Errorf("file not found: %s", spec.ImagePath)
+		} else if os.IsPermission(err) {
+			return stampSource{}, fmt.Errorf("permission denied: %s", spec.ImagePath)
+		} else {
+			return stampSource{}, fmt.Errorf("error opening file: %s", err.Error())
+		}
+	}
+
+	defer iconFile.Close()
+
+	config, _, err := image.DecodeConfig(iconFile)
+	if err != nil {
+		return stampSource{}, err
+	}
+
+	return stampSource{Mode: "image", Operand: spec.ImagePath, Width: float64(config.Width), Height: float64(config.Height)}, nil
+}
+
+// args returns the arguments of the pdfcpu command stamping the source with spec and the given
+// scale on the selected pages of the PDF file.
+func (s stampSource) args(spec StampSpec, selection string, scale string, filePath string) []string {
+	return stampAddArgs(spec, "--pages", selection, "--mode", s.Mode, "--", s.Operand, stampDescription(spec, scale), filePath)
+}
+
+// validateStampSpec checks the position, scale, opacity and offset of spec, zero scale and
+// opacity select the defaults.
+func validateStampSpec(spec StampSpec) error {
+	err := validateStampPosition(spec.Position)
+	if err != nil {
+		return err
+	}
+
+	if spec.Scale < 0 {
+		return fmt.Errorf("invalid stamp scale: %.2f", spec.Scale)
+	}
+
+	if spec.Opacity < 0 || spec.Opacity > 1 {
+		return fmt.Errorf("invalid stamp opacity: %.2f", spec.Opacity)
+	}
+
+	for _, offset := range []float64{spec.OffsetX, spec.OffsetY} {
+		if math.IsNaN(offset) || math.IsInf(offset, 0) {
+			return fmt.Errorf("invalid stamp offset: %g %g", spec.OffsetX, spec.OffsetY)
+		}
+	}
+
+	return nil
+}
+
+// stampDescription returns the pdfcpu description of an image stamp with the given scale
+// and the position, offset, rotation and opacity of spec.
+func stampDescription(spec StampSpec, scale string) string {
+	opacity := spec.Opacity
+	if opacity == 0 {
+		opacity = 1
+	}
+
+	description := fmt.Sprintf("pos:%s, rot:%g, scale:%s, op:%g", spec.Position, spec.Rotation, scale, opacity)
+	if spec.OffsetX != 0 || spec.OffsetY != 0 {
+		description += fmt.Sprintf(", offset:%g %g", spec.OffsetX, spec.OffsetY)
+	}
+
+	return description
+}
+
+// addFittedImageStamp stamps the source so it fits within MaxWidth x MaxHeight points of spec,
+// with an absolute pdfcpu scale computed from the source's own dimensions to keep its aspect ratio.
+func addFittedImageStamp(ctx context.Context, filePath string, source stampSource, spec StampSpec, selection string) error {
+	scale, err := fitScale(source.Width, source.Height, spec.MaxWidth, spec.MaxHeight)
+	if err != nil {
+		return err
+	}
+
+	return runStampCommand(ctx, source.args(spec, selection, fmt.Sprintf("%.4f abs", scale), filePath)...)
+}
+
+// fitScale returns the largest scale that fits an image of imageWidth x imageHeight pixels, or a PDF
+// page of that many points, within maxWidth x maxHeight points. A zero bound leaves that dimension
+// unconstrained.
+func fitScale(imageWidth float64, imageHeight float64, maxWidth float64, maxHeight float64) (float64, error) {
+	if maxWidth < 0 || maxHeight < 0 || (maxWidth == 0 && maxHeight == 0) {
+		return 0, fmt.Errorf("invalid stamp box %.2fx%.2f", maxWidth, maxHeight)
+	}
+
+	if imageWidth <= 0 || imageHeight <= 0 {
+		return 0, fmt.Errorf("invalid stamp dimensions %gx%g", imageWidth, imageHeight)
+	}
+
+	scale := math.Inf(1)
+	if maxWidth > 0 {
+		scale = maxWidth / imageWidth
+	}
+	if maxHeight > 0 {
+		scale = math.Min(scale, maxHeight/imageHeight)
+	}
+
+	return scale, nil
+}
+
+// addRelativeQRCodeToPDF stamps the source so its width is the WidthRatio of spec of each page width.
+// Pages sharing the same width are stamped together with an absolute pdfcpu scale.
+func addRelativeQRCodeToPDF(ctx context.Context, filePath string, source stampSource, spec StampSpec, pages []int) error {
+	sizes, err := pageSizes(ctx, filePath)
+	if err != nil {
+		return err
+	}
+
+	for _, group := range stampScaleGroups(sizes, pages, source.Width, spec.WidthRatio) {
+		err := runStampCommand(ctx, source.args(spec, group.Pages, fmt.Sprintf("%.4f abs", group.Scale), filePath)...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// stampScaleGroup represents a set of pages stamped with the same absolute scale.
+type stampScaleGroup struct {
+	Pages string
+	Scale float64
+}
+
+// stampScaleGroups groups the selected pages by width and computes the absolute scale
+// that makes a stamp of stampWidth pixels take widthRatio of the page width.
+// A nil pages selection groups every page.
+func stampScaleGroups(sizes []pageSize, pages []int, stampWidth float64, widthRatio float64) []stampScaleGroup {
+	if pages == nil {
+		pages = make([]int, len(sizes))
+		for i := range sizes {
+			pages[i] = i + 1
+		}
+	}
+
+	var groups []stampScaleGroup
+	var groupPages [][]int
+	index := make(map[string]int)
+
+	for _, page := range pages {
+		if page < 1 || page > len(sizes) {
+			continue
+		}
+
+		size := sizes[page-1]
+		key := fmt.Sprintf("%.2f", size.Width)
+		n, ok := index[key]
+		if !ok {
+			n = len(groups)
+			index[key] = n
+			groups = append(groups, stampScaleGroup{Scale: size.Width * widthRatio / stampWidth})
+			groupPages = append(groupPages, nil)
+		}
+
+		groupPages[n] = append(groupPages[n], page)
+	}
+
+	for i := range groups {
+		groups[i].Pages = joinPages(groupPages[i])
+	}
+
+	return groups
+}
+
+// orientationPages splits the page numbers into portrait and landscape pages based on their media box.
+// Square pages are treated as portrait.
+func orientationPages(sizes []pageSize) (portrait []int, landscape []int) {
+	for i, size := range sizes {
+		if size.Width > size.Height {
+			landscape = append(landscape, i+1)
+		} else {
+			portrait = append(portrait, i+1)
+		}
+	}
+
+	return portrait, landscape
+}
+
+// addStamps applies the stamp specs of WithStamps in order to the PDF file with the given page count.
+func (p *PDFProcessor) addStamps(ctx context.Context, filePath string, pages int) error {
+	for i, spec := range p.stamps {
+		spec = p.withStampDefaults(spec)
+
+		selected, err := parsePageSelection(spec.Pages, pages)
+		if err != nil {
+			return fmt.Errorf("stamp %d: %w", i+1, err)
+		}
+		if len(selected) == 0 {
+			return fmt.Errorf("stamp %d: no pages selected by %s", i+1, spec.Pages)
+		}
+
+		err = addImageStamp(ctx, filePath, spec, selected)
+		if err != nil {
+			return fmt.Errorf("stamp %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// addOrientationStamps stamps portrait and landscape pages of the PDF file with their own stamp spec.
+func addOrientationStamps(ctx context.Context, filePath string, portrait StampSpec, landscape StampSpec) error {
+	sizes, err := pageSizes(ctx, filePath)
+	if err != nil {
+		return err
+	}
+
+	portraitPages, landscapePages := orientationPages(sizes)
+
+	if len(portraitPages) > 0 {
+		err := addImageStamp(ctx, filePath, portrait, portraitPages)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(landscapePages) > 0 {
+		err := addImageStamp(ctx, filePath, landscape, landscapePages)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// joinPages formats the page numbers as a pdfcpu page selection.
+func joinPages(pages []int) string {
+	selection := make([]string, len(pages))
+	for i, page := range pages {
+		selection[i] = strconv.Itoa(page)
+	}
+
+	return strings.Join(selection, ",")
+}
+
+// parsePageSelection resolves a pdfcpu page selection against a document of count pages.
+// It supports "even", "odd", page numbers, ranges such as "1-3", "5-" and "-2", "l" for the last page,
+// "l-1" for the page before it, and exclusions prefixed with "!" or "n". A selection starting with
+// an exclusion starts from every page. Pages beyond count are ignored.
+func parsePageSelection(selector string, count int) ([]int, error) {
+	if strings.TrimSpace(selector) == "" {
+		return nil, errors.New("stamp pages selector is empty")
+	}
+
+	selected := make(map[int]bool)
+	for i, term := range strings.Split(selector, ",") {
+		term = strings.TrimSpace(term)
+
+		exclude := strings.HasPrefix(term, "!") || strings.HasPrefix(term, "n")
+		if exclude {
+			term = term[1:]
+			if i == 0 {
+				for page := 1; page <= count; page++ {
+					selected[page] = true
+				}
+			}
+		}
+
+		first, last, err := pageRange(term, count)
+		if err != nil {
+			return nil, fmt.Errorf("invalid page selector %q: %s", selector, err.Error())
+		}
+
+		for page := first; page <= last && page <= count; page++ {
+			switch {
+			case term == "even" && page%2 != 0, term == "odd" && page%2 == 0:
+				continue
+			case exclude:
+				delete(selected, page)
+			default:
+				selected[page] = true
+			}
+		}
+	}
+
+	pages := make([]int, 0, len(selected))
+	for page := range selected {
+		pages = append(pages, page)
+	}
+	sort.Ints(pages)
+
+	return pages, nil
+}
+
+// pageRange resolves a single page selector term to the first and last page it covers.
+func pageRange(term string, count int) (int, int, error) {
+	switch term {
+	case "":
+		return 0, 0, errors.New("empty term")
+	case "even", "odd":
+		return 1, count, nil
+	}
+
+	bounds := strings.SplitN(term, "-", 2)
+	if bounds[0] == "l" && len(bounds) == 2 {
+		// l-N selects the page N before the last one
+		n, err := strconv.Atoi(bounds[1])
+		if err != nil || n < 0 {
+			return 0, 0, fmt.Errorf("invalid term %s", term)
+		}
+
+		// Without a page count, as when validating options, any N is accepted
+		if count > 0 && n >= count {
+			return 0, 0, fmt.Errorf("term %s is before the first of %d pages", term, count)
+		}
+
+		return count - n, count - n, nil
+	}
+
+	first, err := pageNumber(bounds[0], 1, count)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if len(bounds) == 1 {
+		return first, first, nil
+	}
+
+	last, err := pageNumber(bounds[1], count, count)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return first, last, nil
+}
+
+// pageNumber parses a page number of a selector term, "l" being the last page.
+// An empty value, as in the open ranges "5-" and "-2", resolves to fallback.
+func pageNumber(value string, fallback int, count int) (int, error) {
+	switch value {
+	case "":
+		return fallback, nil
+	case "l":
+		return count, nil
+	}
+
+	page, err := strconv.Atoi(value)
+	if err != nil || page < 1 {
+		return 0, fmt.Errorf("invalid page %s", value)
+	}
+
+	return page, nil
+}
+
+// pdfcpuConfigKey is the context key of the pdfcpu configuration directory.
+type pdfcpuConfigKey struct{}
+
+// withPDFCPUConfigDir returns a copy of ctx that runs pdfcpu with its configuration in dir.
+func withPDFCPUConfigDir(ctx context.Context, dir string) context.Context {
+	return context.WithValue(ctx, pdfcpuConfigKey{}, dir)
+}
+
+// pdfcpuCommand returns the command to run pdfcpu with args. The configuration directory
+// set on ctx is passed with --conf, otherwise pdfcpu uses the user configuration.
+func pdfcpuCommand(ctx context.Context, args ...string) *exec.Cmd {
+	if dir, ok := ctx.Value(pdfcpuConfigKey{}).(string); ok && dir != "" {
+		args = append([]string{"--conf", dir}, args...)
+	}
+
+	return exec.CommandContext(ctx, executable("pdfcpu"), args...)
+}
+
+// PDFCPUError is returned when a pdfcpu command fails. It holds the command line, with passwords
+// redacted, and the error output of pdfcpu, so failures can be inspected without parsing messages.
+type PDFCPUError struct {
+	Cmd    string
+	Stderr string
+	Err    error
+}
+
+// Error returns the error of the command followed by the error output of pdfcpu.
+func (e *PDFCPUError) Error() string {
+	if e.Stderr == "" {
+		return fmt.Sprintf("error executing pdfcpu command: %s", e.Err.Error())
+	}
+
+	return fmt.Sprintf("error executing pdfcpu command: %s: %s", e.Err.Error(), e.Stderr)
+}
+
+// Unwrap returns the error of the command, such as an *exec.ExitError.
+func (e *PDFCPUError) Unwrap() error {
+	return e.Err
+}
+
+// runPDFCPU runs pdfcpu with args and returns its standard output. In a dry run, commands that
+// change a file are written to the writer of WithDryRun instead and return no output.
+// A failure is returned as a *PDFCPUError holding the error output.
+// pdfcpu is executed directly, never through a shell, so arguments are neither interpreted nor
+// written to a shell history. pdfcpu only accepts passwords as --upw and --opw arguments though,
+// which other users of the host can read from the process table while the command runs.
+func runPDFCPU(ctx context.Context, args ...string) ([]byte, error) {
+	if w, ok := ctx.Value(dryRunKey{}).(io.Writer); ok && !readOnlyPDFCPUCommand(args) {
+		line := redactedPDFCPUCommand(args)
+		for i, arg := range line {
+			line[i] = shellQuote(arg)
+		}
+
+		_, err := fmt.Fprintln(w, strings.Join(line, " "))
+		return nil, err
+	}
+
+	var stderr bytes.Buffer
+
+	// Execute the command
+	cmd := pdfcpuCommand(ctx, args...)
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, newPDFCPUError(args, stderr.Bytes(), err)
+	}
+
+	return output, nil
+}
 
-		// Delete the temporary PDF file
-		err = os.Remove(pdfFilePath)
-		if err != nil {
-			return err
+// newPDFCPUError returns the *PDFCPUError of a pdfcpu command with args that failed with err.
+func newPDFCPUError(args []string, stderr []byte, err error) *PDFCPUError {
+	return &PDFCPUError{Cmd: pdfcpuCommandLine(args), Stderr: strings.TrimSpace(string(stderr)), Err: err}
+}
+
+// pdfcpuCommandLine returns the pdfcpu command line of args with the values of password flags redacted.
+func pdfcpuCommandLine(args []string) string {
+	return strings.Join(redactedPDFCPUCommand(args), " ")
+}
+
+// redactedPDFCPUCommand returns the pdfcpu command of args with the values of password flags redacted.
+func redactedPDFCPUCommand(args []string) []string {
+	line := append([]string{"pdfcpu"}, args...)
+	for i := 1; i < len(line); i++ {
+		switch {
+		case line[i-1] == "--upw" || line[i-1] == "--opw":
+			line[i] = "***"
+		case strings.HasPrefix(line[i], "--upw=") || strings.HasPrefix(line[i], "--opw="):
+			line[i] = line[i][:len("--upw=")] + "***"
 		}
-	default:
-		return errors.New("unsupported file type")
 	}
 
-	return nil
+	return line
 }
 
-// pdfToBase64 converts a PDF file to base64 encoding.
-func (p *PDFProcessor) pdfToBase64(filePath string) error {
-	// Read the PDF file into memory.
-	pdfFile, err := os.ReadFile(filePath)
-	if err != nil {
-		return err
+// dryRunKey is the context key of the writer receiving the commands of a dry run.
+type dryRunKey struct{}
+
+// WithDryRun returns an Option function that writes every pdfcpu command that would change a file
+// to w instead of running it, one shell quoted command line per line with passwords redacted.
+// Commands that only read a file, such as info and validate, still run to plan the next steps.
+// Steps done without pdfcpu, such as adding the StampAltText, are listed as shell comments.
+// The output file is still copied from the input, it is left unchanged. A protected input stays
+// encrypted, so it can't be read past the skipped decrypt command. Lines are written from the
+// goroutine processing the file, w must be safe for concurrent use with ProcessBatch.
+func WithDryRun(w io.Writer) Option {
+	return func(p *PDFProcessor) {
+		p.dryRun = w
 	}
-	// Encode the PDF file as base64.
-	base64Output := base64.StdEncoding.EncodeToString(pdfFile)
-	// Set the Base64Output field of the PDFProcessor struct.
-	p.Base64Output = base64Output
-
-	return nil
 }
 
-// getFileType returns the type of file based on its extension.
-func getFileType(filePath string) FileType {
-	extension := strings.ToLower(filepath.Ext(filePath))
-	switch extension {
-	case ".pdf":
-		return PDF
-	case ".jpg", ".jpeg", ".png":
-		return Image
-	case ".doc", ".docx":
-		return Document
+// readOnlyPDFCPUCommand reports whether the pdfcpu command of args leaves every file unchanged.
+func readOnlyPDFCPUCommand(args []string) bool {
+	if len(args) >= 2 && args[0] == "--conf" {
+		args = args[2:]
+	}
+
+	switch {
+	case len(args) == 0:
+		return false
+	case args[0] == "info" || args[0] == "validate" || args[0] == "version":
+		return true
 	default:
-		return ""
+		return len(args) > 1 && args[1] == "list"
 	}
 }
 
-// hasPDFPassword checks if the PDF file is password-protected.
-func hasPDFPassword(filePath string, password string) (bool, error) {
-	command := ""
-	if password != "" {
-		command = fmt.Sprintf("pdfcpu validate -mode=quiet -upw='%s' %s", password, filePath)
-	} else {
-		command = fmt.Sprintf("pdfcpu validate %s", filePath)
+// shellQuote quotes arg for a POSIX shell unless it only holds characters that need no quoting.
+func shellQuote(arg string) string {
+	if arg != "" && strings.Trim(arg, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_=+./:,*@%") == "" {
+		return arg
 	}
 
-	// Execute the command
-	cmd := exec.Command("sh", "-c", command)
-	err := cmd.Run()
-	if err != nil {
-		exitError, ok := err.(*exec.ExitError)
-		if ok && exitError.ExitCode() == 1 {
-			// PDF is password protected
-			return true, nil
-		} else {
-			// Other execution error
-			return false, exitError
-		}
-	} else {
-		// PDF is not password protected
-		return false, err
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// exitCode returns the exit code of the command that failed with err, or -1 when it didn't exit.
+func exitCode(err error) int {
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) {
+		return exitError.ExitCode()
 	}
+
+	return -1
 }
 
-// decrypted unction is used to remove the protection from a PDF file by decrypting it with a provided password.
-func decrypted(filePath string, password string) error {
-	command := fmt.Sprintf("pdfcpu decrypt -upw %s %s", password, filePath)
+// stampAddArgs returns the arguments of a pdfcpu stamp add command for spec, the extra flags
+// of spec precede args.
+func stampAddArgs(spec StampSpec, args ...string) []string {
+	return append(append([]string{"stamp", "add"}, spec.extraFlags...), args...)
+}
 
-	// Execute the command
-	cmd := exec.Command("sh", "-c", command)
-	err := cmd.Run()
-	if err != nil {
-		fmt.Printf("Error executing pdfcpu command: %s\n", err.Error())
-		return err
+// validateExtraFlags checks that flags only holds pdfcpu flags, so they can't add operands
+// such as input files to a command.
+func validateExtraFlags(flags []string) error {
+	for _, flag := range flags {
+		if !strings.HasPrefix(flag, "-") || flag == "-" || flag == "--" {
+			return fmt.Errorf("invalid extra flag %q: flags must start with a dash and attach their value with =", flag)
+		}
 	}
 
 	return nil
+}
 
+// runStampCommand executes a pdfcpu stamp command with the given arguments.
+func runStampCommand(ctx context.Context, args ...string) error {
+	_, err := runPDFCPU(ctx, args...)
+	return err
 }
 
-// encrypted function is used to encrypt a previously decrypted PDF.
-func encrypted(filePath string, password string) error {
-	command := fmt.Sprintf("pdfcpu encrypt -upw %s -opw %s %s", password, password, filePath)
+// CollisionPolicy represents what happens when the output file of a generated QR code already exists.
+type CollisionPolicy string
 
-	// Execute the command
-	cmd := exec.Command("sh", "-c", command)
-	err := cmd.Run()
-	if err != nil {
-		fmt.Printf("Error executing pdfcpu command: %s\n", err.Error())
-		return err
-	}
+// Constants for the output file collision policies.
+const (
+	// CollisionOverwrite replaces the existing file.
+	CollisionOverwrite CollisionPolicy = "overwrite"
+	// CollisionError fails with an error wrapping os.ErrExist.
+	CollisionError CollisionPolicy = "error"
+	// CollisionSuffix writes to the first free name with a numeric suffix, e.g. qr-generate-1.png.
+	CollisionSuffix CollisionPolicy = "suffix"
+)
 
-	return nil
+// ErrorCorrection represents the error correction level of a generated QR code.
+// Higher levels tolerate a larger icon but need more modules for the same data.
+type ErrorCorrection string
+
+// Constants for the QR code error correction levels.
+const (
+	// ErrorCorrectionL recovers about 7% of the code.
+	ErrorCorrectionL ErrorCorrection = "L"
+	// ErrorCorrectionM recovers about 15% of the code.
+	ErrorCorrectionM ErrorCorrection = "M"
+	// ErrorCorrectionQ recovers about 25% of the code.
+	ErrorCorrectionQ ErrorCorrection = "Q"
+	// ErrorCorrectionH recovers about 30% of the code.
+	ErrorCorrectionH ErrorCorrection = "H"
+)
+
+// errorCorrectionLevels maps the error correction levels to the encoder level and the share of
+// the code it recovers.
+var errorCorrectionLevels = map[ErrorCorrection]struct {
+	level    qr.ErrorCorrectionLevel
+	recovery float64
+}{
+	ErrorCorrectionL: {qr.L, 0.07},
+	ErrorCorrectionM: {qr.M, 0.15},
+	ErrorCorrectionQ: {qr.Q, 0.25},
+	ErrorCorrectionH: {qr.H, 0.30},
 }
 
-// processPDF performs operations on the PDF file using pdfcpu-cli.
-func (p *PDFProcessor) processPDF(filePath string, qrCode string, stampPosition string) error {
-	// Add QR code to the PDF file
-	err := addQRCodeToPDF(filePath, qrCode, stampPosition)
-	if err != nil {
-		return err
+// QROption is a function type used for applying options to QR code generation.
+type QROption func(*qrConfig)
+
+// qrConfig holds the options applied when generating a QR code.
+type qrConfig struct {
+	collision       CollisionPolicy
+	clearZone       bool
+	padding         int
+	radius          int
+	size            int
+	iconSize        int
+	errorCorrection ErrorCorrection
+}
+
+// WithCollisionPolicy returns a QROption function that sets how an existing output file is handled.
+// The default CollisionOverwrite replaces it.
+func WithCollisionPolicy(policy CollisionPolicy) QROption {
+	return func(c *qrConfig) {
+		c.collision = policy
 	}
+}
 
-	//add metadata to file pdf
-	if !IsStructEmpty(p.OptionMetadataPDF) {
-		err := addedMetadata(filePath, p.OptionMetadataPDF)
-		if err != nil {
-			return err
-		}
+// WithQRSize returns a QROption function that sets the width and height of the QR code image
+// in pixels, 125 by default.
+func WithQRSize(size int) QROption {
+	return func(c *qrConfig) {
+		c.size = size
 	}
+}
 
-	//add protection to file pdf
-	if p.PDFProtection {
-		err := encrypted(filePath, p.OptionFilePDF.PasswordPDF)
-		if err != nil {
-			return err
-		}
+// WithIconSize returns a QROption function that sets the width and height of the icon in pixels,
+// 30 by default.
+func WithIconSize(size int) QROption {
+	return func(c *qrConfig) {
+		c.iconSize = size
 	}
+}
 
-	//Convert pdf file to base64 as output file
-	err = p.pdfToBase64(filePath)
-	if err != nil {
-		return err
+// WithErrorCorrection returns a QROption function that sets the error correction level,
+// ErrorCorrectionM by default. Raise it when a large icon makes the code unreadable.
+func WithErrorCorrection(level ErrorCorrection) QROption {
+	return func(c *qrConfig) {
+		c.errorCorrection = level
 	}
+}
 
-	return nil
+// WithIconClearZone returns a QROption function that clears a white zone of padding pixels around
+// the icon, so modules don't run into it, and rounds the corners of the icon with radius pixels.
+func WithIconClearZone(padding int, radius int) QROption {
+	return func(c *qrConfig) {
+		c.clearZone = true
+		c.padding = padding
+		c.radius = radius
+	}
 }
 
-// addedMetadata to add metadata into a pdf file.
-func addedMetadata(filePath string, metadata *OptionMetadataPDF) error {
-	command := fmt.Sprintf("pdfcpu properties add %s 'Title = %s' 'Author = %s' 'Subject = %s'", filePath, metadata.Title, metadata.Author, metadata.Subject)
+// roundedRect is an alpha mask that is opaque inside a rectangle with rounded corners.
+type roundedRect struct {
+	rect   image.Rectangle
+	radius int
+}
 
-	// Execute the command
-	cmd := exec.Command("sh", "-c", command)
-	err := cmd.Run()
-	if err != nil {
-		return err
-	}
+// ColorModel implements the image.Image interface.
+func (r roundedRect) ColorModel() color.Model {
+	return color.AlphaModel
+}
 
-	return nil
+// Bounds implements the image.Image interface.
+func (r roundedRect) Bounds() image.Rectangle {
+	return r.rect
 }
 
-// addQRCodeToPDF adds a QR code to the PDF file using pdfcpu-cli.
-func addQRCodeToPDF(filePath string, qrCode string, stampPosition string) error {
-	if qrCode == "" {
-		return errors.New("QR Code is empty")
+// At implements the image.Image interface.
+func (r roundedRect) At(x, y int) color.Color {
+	if !image.Pt(x, y).In(r.rect) {
+		return color.Transparent
 	}
 
-	// Load the icon image
-	iconFile, err := os.Open(qrCode)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return fmt.Errorf("file not found: %s", filePath)
-		} else {
-			return fmt.Errorf("error opening file: %s", err.Error())
-		}
+	// Distance to the center of the nearest corner circle, zero outside the corners
+	dx := cornerDistance(x, r.rect.Min.X+r.radius, r.rect.Max.X-1-r.radius)
+	dy := cornerDistance(y, r.rect.Min.Y+r.radius, r.rect.Max.Y-1-r.radius)
+	if dx*dx+dy*dy > r.radius*r.radius {
+		return color.Transparent
 	}
 
-	defer iconFile.Close()
+	return color.Opaque
+}
 
-	command := fmt.Sprintf("pdfcpu stamp add -pages even,odd -mode image -- '%s' 'pos:%s, rot:0, sc:.1' %s", iconFile.Name(), stampPosition, filePath)
+// cornerDistance returns how far v lies outside the range from low to high, zero within it.
+func cornerDistance(v int, low int, high int) int {
+	switch {
+	case v < low:
+		return low - v
+	case v > high:
+		return v - high
+	default:
+		return 0
+	}
+}
 
-	// Execute the command
-	cmd := exec.Command("sh", "-c", command)
+// GenerateQRCode generate QR Code without an icon.
+// It returns the path the QR code was written to, which differs from filePath with CollisionSuffix.
+// Icon options such as WithIconSize and WithIconClearZone are ignored.
+func GenerateQRCode(data string, filePath string, options ...QROption) (string, error) {
+	config, err := newQRConfig(options)
+	if err != nil {
+		return "", err
+	}
 
-	err = cmd.Run()
+	qrCode, err := encodeQRCode(data, config)
 	if err != nil {
-		if exitError, ok := err.(*exec.ExitError); ok {
-			// Command exited with a non-zero status
-			fmt.Printf("Command failed with error: %s\n", exitError.Error())
-			if len(exitError.Stderr) > 0 {
-				return fmt.Errorf("error output:%s", string(exitError.Stderr))
-			}
-		} else {
-			// Other execution error
-			return fmt.Errorf("error executing pdfcpu command: %s", err.Error())
-		}
+		return "", err
 	}
 
-	return nil
+	return saveQRCode(rgbaImage(qrCode), filePath, config.collision)
 }
 
-// generateThumbnailFromPDF generates a thumbnail from the PDF.
-// func generateThumbnailFromPDF(filePath string) error {
-// 	panic("implement me")
-// }
-
 // GenerateQRCodeWithIcon generate QR Code with icon in the center position.
-func GenerateQRCodeWithIcon(data string, iconPath string, filePath string) (string, error) {
-	// Create a new QR code barcode with the given data
-	qrCode, err := qr.Encode(data, qr.M, qr.Auto)
+// It returns the path the QR code was written to, which differs from filePath with CollisionSuffix.
+// An error is returned when the icon hides more of the code than the error correction level recovers.
+func GenerateQRCodeWithIcon(data string, iconPath string, filePath string, options ...QROption) (string, error) {
+	config, err := newQRConfig(options)
 	if err != nil {
 		return "", err
 	}
 
-	// Scale the barcode to the desired size
-	qrCode, err = barcode.Scale(qrCode, 125, 125)
+	err = checkIconFits(config)
+	if err != nil {
+		return "", err
+	}
+
+	qrCode, err := encodeQRCode(data, config)
 	if err != nil {
 		return "", err
 	}
@@ -374,7 +2531,7 @@ func GenerateQRCodeWithIcon(data string, iconPath string, filePath string) (stri
 		return "", err
 	}
 
-	resizeIcon := image.NewRGBA(image.Rect(0, 0, 30, 30))
+	resizeIcon := image.NewRGBA(image.Rect(0, 0, config.iconSize, config.iconSize))
 
 	draw.CatmullRom.Scale(resizeIcon, resizeIcon.Bounds(), iconImg, iconImg.Bounds(), draw.Over, nil)
 
@@ -384,98 +2541,425 @@ func GenerateQRCodeWithIcon(data string, iconPath string, filePath string) (stri
 	// Calculate the position to place the icon in the center of the QR code
 	iconX := (qrCode.Bounds().Max.X - resizeIcon.Bounds().Max.X) / 2
 	iconY := (qrCode.Bounds().Max.Y - resizeIcon.Bounds().Max.Y) / 2
+	if iconX < 0 || iconY < 0 {
+		return "", fmt.Errorf("icon size %d exceeds QR code size %dx%d", config.iconSize, qrCode.Bounds().Dx(), qrCode.Bounds().Dy())
+	}
 
 	// Draw the QR code onto the final image
 	draw.Draw(finalImg, qrCode.Bounds().Add(image.Point{}), qrCode, image.Point{}, draw.Over)
 
 	// Draw the icon onto the final image
-	draw.Draw(finalImg, resizeIcon.Bounds().Add(image.Pt(iconX, iconY)), resizeIcon, image.Point{}, draw.Over)
+	iconRect := resizeIcon.Bounds().Add(image.Pt(iconX, iconY))
+	if config.clearZone {
+		// Punch a white zone behind the icon and round the corners of both
+		zone := roundedRect{rect: iconRect.Inset(-config.padding), radius: config.radius + config.padding}
+		draw.DrawMask(finalImg, zone.rect, image.White, image.Point{}, zone, zone.rect.Min, draw.Over)
+
+		mask := roundedRect{rect: resizeIcon.Bounds(), radius: config.radius}
+		draw.DrawMask(finalImg, iconRect, resizeIcon, image.Point{}, mask, image.Point{}, draw.Over)
+	} else {
+		draw.Draw(finalImg, iconRect, resizeIcon, image.Point{}, draw.Over)
+	}
+
+	return saveQRCode(finalImg, filePath, config.collision)
+}
+
+// newQRConfig applies the QR options to the default configuration and validates the result.
+func newQRConfig(options []QROption) (qrConfig, error) {
+	config := qrConfig{collision: CollisionOverwrite, size: 125, iconSize: 30, errorCorrection: ErrorCorrectionM}
+	for _, opt := range options {
+		opt(&config)
+	}
 
-	// Create a new file to save the QR code image with the icon
-	file, err := os.Create(filePath)
+	if config.padding < 0 || config.radius < 0 {
+		return config, fmt.Errorf("invalid icon clear zone: padding %d, radius %d", config.padding, config.radius)
+	}
+
+	if config.size <= 0 {
+		return config, fmt.Errorf("invalid QR code size: %d", config.size)
+	}
+
+	if config.iconSize <= 0 {
+		return config, fmt.Errorf("invalid icon size: %d", config.iconSize)
+	}
+
+	if _, ok := errorCorrectionLevels[config.errorCorrection]; !ok {
+		return config, fmt.Errorf("invalid error correction level: %q", config.errorCorrection)
+	}
+
+	return config, nil
+}
+
+// checkIconFits returns an error when the icon, including its clear zone, doesn't fit the QR code
+// or hides more of it than the error correction level recovers.
+func checkIconFits(config qrConfig) error {
+	side := config.iconSize
+	if config.clearZone {
+		side += 2 * config.padding
+	}
+
+	// A larger icon would be clipped and placed off center
+	if side > config.size {
+		return fmt.Errorf("icon size %d exceeds QR code size %d", side, config.size)
+	}
+
+	coverage := float64(side*side) / float64(config.size*config.size)
+	recovery := errorCorrectionLevels[config.errorCorrection].recovery
+	if coverage > recovery {
+		return fmt.Errorf("icon covers %.1f%% of the QR code, error correction level %s recovers %.0f%%", coverage*100, config.errorCorrection, recovery*100)
+	}
+
+	return nil
+}
+
+// encodeQRCode encodes data as a QR code scaled to the configured size.
+func encodeQRCode(data string, config qrConfig) (barcode.Barcode, error) {
+	// Create a new QR code barcode with the given data
+	qrCode, err := qr.Encode(data, errorCorrectionLevels[config.errorCorrection].level, qr.Auto)
+	if err != nil {
+		return nil, err
+	}
+
+	// Scale the barcode to the desired size
+	return barcode.Scale(qrCode, config.size, config.size)
+}
+
+// saveQRCode writes the QR code image as a PNG file at filePath according to the collision
+// policy and returns the path it was written to.
+func saveQRCode(img image.Image, filePath string, policy CollisionPolicy) (string, error) {
+	// Create a new file to save the QR code image
+	file, err := createOutputFile(filePath, policy)
 	if err != nil {
 		return "", err
 	}
 	defer file.Close()
 
 	// Save the final image as a PNG file
-	err = png.Encode(file, finalImg)
+	err = png.Encode(file, img)
+	if err != nil {
+		return "", err
+	}
+
+	return file.Name(), nil
+}
+
+// createOutputFile creates the file at filePath, handling an existing file according to policy.
+func createOutputFile(filePath string, policy CollisionPolicy) (*os.File, error) {
+	switch policy {
+	case CollisionOverwrite:
+		return os.Create(filePath)
+	case CollisionError:
+		return os.OpenFile(filePath, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+	case CollisionSuffix:
+		extension := filepath.Ext(filePath)
+		base := strings.TrimSuffix(filePath, extension)
+
+		candidate := filePath
+		for i := 1; ; i++ {
+			file, err := os.OpenFile(candidate, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+			if !errors.Is(err, os.ErrExist) {
+				return file, err
+			}
+
+			candidate = fmt.Sprintf("%s-%d%s", base, i, extension)
+		}
+	default:
+		return nil, fmt.Errorf("invalid collision policy: %s", policy)
+	}
+}
+
+// imageConverter converts images to PDF, it is replaced in tests to observe conversions.
+var imageConverter = convertImageToPDF
+
+// createProcessedFile creates an empty file for the PDF processed from filePath, in dir or next to
+// filePath when dir is empty, and returns its path. The name is prefixed with "process-" and gets
+// a random suffix, so concurrent and repeated runs on files with the same name don't collide.
+func createProcessedFile(filePath string, dir string) (string, error) {
+	outputFile := changeFileExtension(filePath, "pdf")
+	if dir == "" {
+		dir = filepath.Dir(outputFile)
+	}
+
+	// The random part replaces the "*", keep it out of the pattern taken from the name
+	name := strings.ReplaceAll(strings.TrimSuffix(filepath.Base(outputFile), ".pdf"), "*", "")
+
+	file, err := os.CreateTemp(dir, "process-"+name+"-*.pdf")
 	if err != nil {
 		return "", err
 	}
 
-	return filePath, nil
+	return file.Name(), file.Close()
 }
 
-// convertImageToPDF converts an image file to PDF using package gofpdf.
-func convertImageToPDF(imageFilePath string) (string, error) {
+// gofpdfImageTypes lists the image.Decode formats gofpdf embeds directly.
+var gofpdfImageTypes = map[string]bool{"jpeg": true, "png": true, "gif": true}
+
+// convertImageToPDF converts an image file to PDF using package gofpdf, written to outputDir
+// or next to the image when outputDir is empty.
+// Every page of a multi-page TIFF file becomes a page of the PDF file, oriented like the first page.
+func convertImageToPDF(imageFilePath string, outputDir string, conversion imageConversion) (string, error) {
 	// Open the input image file
-	outputFile := fmt.Sprintf("process-%s", changeFileExtension(imageFilePath, "pdf"))
-	file, err := os.Open(imageFilePath)
+	data, err := os.ReadFile(imageFilePath)
 	if err != nil {
 		return "", err
 	}
-	defer file.Close()
 
-	// Read the image file
-	img, _, err := image.Decode(file)
+	// Read the image file, the format names the image type for files without an extension
+	frames, format, err := decodeImageFrames(data)
+	if err != nil {
+		return "", err
+	}
+
+	// Reject degenerate images, they would produce a NaN or infinite placement
+	for _, frame := range frames {
+		bounds := frame.Bounds()
+		if bounds.Dx() <= 0 || bounds.Dy() <= 0 {
+			return "", fmt.Errorf("invalid image dimensions %dx%d: %s", bounds.Dx(), bounds.Dy(), imageFilePath)
+		}
+	}
+
+	size, orientation, err := conversion.pageLayout(frames[0].Bounds())
 	if err != nil {
 		return "", err
 	}
 
+	if conversion.DespeckleSize > 0 && conversion.DespeckleSize%2 == 0 {
+		return "", fmt.Errorf("despeckle kernel size must be odd: %d", conversion.DespeckleSize)
+	}
+
+	if conversion.ImageDPI < 0 {
+		return "", fmt.Errorf("invalid image DPI: %d", conversion.ImageDPI)
+	}
+
+	if conversion.JPEGQuality < 0 || conversion.JPEGQuality > 100 {
+		return "", fmt.Errorf("invalid JPEG quality: %d", conversion.JPEGQuality)
+	}
+
 	// Create a new PDF document
-	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf := gofpdf.New(orientation, "mm", size, "")
+	pdf.SetCompression(!conversion.DisableCompression)
+
+	if conversion.EXIFMetadata {
+		err = applyEXIFMetadata(pdf, imageFilePath)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	for i, img := range frames {
+		bounds := img.Bounds()
+
+		// Add a new page, every frame is fitted to the orientation of the first one
+		pdf.AddPage()
 
-	// Add a new page
-	pdf.AddPage()
+		// Calculate the aspect ratio of the image
+		aspectRatio := float64(bounds.Dx()) / float64(bounds.Dy())
 
-	// Calculate the aspect ratio of the image
-	aspectRatio := float64(img.Bounds().Dx()) / float64(img.Bounds().Dy())
+		// Set the image size to fit the page width
+		pageWidth, pageHeight := pdf.GetPageSize()
+		imageWidth := pageWidth
+		imageHeight := imageWidth / aspectRatio
 
-	// Set the image size to fit the page width
-	pageWidth, pageHeight := pdf.GetPageSize()
-	imageWidth := pageWidth
-	imageHeight := imageWidth / aspectRatio
+		// Fit very tall images to the page height instead of overflowing the page
+		if imageHeight > pageHeight {
+			imageHeight = pageHeight
+			imageWidth = imageHeight * aspectRatio
+		}
+
+		// Keep the printed size of images that fit the page at the DPI, downsample the others
+		resampled := false
+		if dpi := float64(conversion.ImageDPI); dpi > 0 {
+			printedWidth := float64(bounds.Dx()) / dpi * 25.4
+			printedHeight := float64(bounds.Dy()) / dpi * 25.4
+			if printedWidth <= imageWidth {
+				imageWidth, imageHeight = printedWidth, printedHeight
+			} else {
+				img = resampleImage(img, imageWidth/25.4*dpi, imageHeight/25.4*dpi)
+				resampled = true
+			}
+		}
+
+		// Remove scanner noise and embed the filtered image instead of the original file,
+		// gofpdf can't read formats such as WebP and TIFF, embed them as 8-bit PNG.
+		// JPEG images stay JPEG when they are re-encoded or downsampled.
+		imageName := imageFilePath
+		reencodeJPEG := format == "jpeg" && (conversion.JPEGQuality > 0 || resampled)
+		if conversion.DespeckleSize > 0 || !gofpdfImageTypes[format] || resampled || reencodeJPEG {
+			var embedded image.Image
+			var prefix string
+			if conversion.DespeckleSize > 0 {
+				embedded, prefix = medianFilter(img, conversion.DespeckleSize), "despeckled"
+			} else {
+				embedded, prefix = rgbaImage(img), "converted"
+			}
+
+			var buf bytes.Buffer
+			imageType := "PNG"
+			if reencodeJPEG {
+				quality := conversion.JPEGQuality
+				if quality == 0 {
+					quality = jpeg.DefaultQuality
+				}
+
+				imageType = "JPG"
+				err = jpeg.Encode(&buf, embedded, &jpeg.Options{Quality: quality})
+			} else {
+				err = png.Encode(&buf, embedded)
+			}
+			if err != nil {
+				return "", err
+			}
+
+			imageName = fmt.Sprintf("%s-%d-%s", prefix, i, filepath.Base(imageFilePath))
+			pdf.RegisterImageOptionsReader(imageName, gofpdf.ImageOptions{ImageType: imageType}, &buf)
+		}
 
-	// Calculate the vertical position to center the image
-	imageY := (pageHeight - imageHeight) / 2
+		// Calculate the position to center the image
+		imageX := (pageWidth - imageWidth) / 2
+		imageY := (pageHeight - imageHeight) / 2
 
-	// Add the image to the PDF
-	pdf.ImageOptions(imageFilePath, 0, imageY, imageWidth, imageHeight, false, gofpdf.ImageOptions{}, 0, "")
+		// Add the image to the PDF
+		pdf.ImageOptions(imageName, imageX, imageY, imageWidth, imageHeight, false, gofpdf.ImageOptions{ImageType: format}, 0, "")
+	}
 
 	// Save the PDF to the output file
+	outputFile, err := createProcessedFile(imageFilePath, outputDir)
+	if err != nil {
+		return "", err
+	}
+
 	err = pdf.OutputFileAndClose(outputFile)
+	if err != nil {
+		os.Remove(outputFile)
+		return "", err
+	}
+
+	return outputFile, nil
+}
+
+// convertDocument converts the input document to PDF within the conversion timeout.
+func (p *PDFProcessor) convertDocument(ctx context.Context) (string, error) {
+	if p.conversionTimeout <= 0 {
+		return convertDocumentToPDF(ctx, p.FilePath, p.tempDir)
+	}
+
+	convertCtx, cancel := context.WithTimeout(ctx, p.conversionTimeout)
+	defer cancel()
+
+	pdfFilePath, err := convertDocumentToPDF(convertCtx, p.FilePath, p.tempDir)
+	if err != nil && ctx.Err() == nil && convertCtx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("soffice conversion timed out after %s: %s", p.conversionTimeout, p.FilePath)
+	}
+
+	return pdfFilePath, err
+}
+
+// convertDocumentToPDF converts a document file in any format LibreOffice opens, such as DOCX,
+// ODT, RTF, XLSX or PPTX, to PDF using LibreOffice in headless mode.
+// The PDF is written to outputDir, or next to the document when empty, with the "process-" prefix.
+func convertDocumentToPDF(ctx context.Context, documentFilePath string, outputDir string) (string, error) {
+	_, err := exec.LookPath(executable("soffice"))
+	if err != nil {
+		return "", fmt.Errorf("LibreOffice is required to convert documents, soffice not found: %s", err.Error())
+	}
+
+	// Convert into a temporary directory, soffice names the output after the document
+	sofficeDir, err := os.MkdirTemp(outputDir, "soffice-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(sofficeDir)
+
+	// Use a profile of our own, concurrent conversions sharing the default profile hand their
+	// documents to the first instance or fail
+	profile, err := fileURL(filepath.Join(sofficeDir, "profile"))
+	if err != nil {
+		return "", err
+	}
+
+	// Execute the command
+	cmd := exec.CommandContext(ctx, executable("soffice"), "-env:UserInstallation="+profile, "--headless", "--convert-to", "pdf", "--outdir", sofficeDir, documentFilePath)
+	killProcessGroupOnCancel(cmd)
+	// Don't wait for the output of processes that escaped the process group
+	cmd.WaitDelay = 5 * time.Second
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		removeLockFile(documentFilePath)
+		return "", fmt.Errorf("error executing soffice command: %s: %s", err.Error(), strings.TrimSpace(string(output)))
+	}
+
+	convertedPath := filepath.Join(sofficeDir, filepath.Base(changeFileExtension(documentFilePath, "pdf")))
+	if _, err := os.Stat(convertedPath); err != nil {
+		removeLockFile(documentFilePath)
+		return "", fmt.Errorf("soffice produced no PDF for %s: %s", documentFilePath, strings.TrimSpace(string(output)))
+	}
+
+	outputFile, err := createProcessedFile(documentFilePath, outputDir)
 	if err != nil {
 		return "", err
 	}
 
+	err = copyFile(convertedPath, outputFile)
+	if err != nil {
+		os.Remove(outputFile)
+		return "", err
+	}
+
 	return outputFile, nil
 }
 
-// convertDocumentToPDF converts a document file to PDF using pdfcpu-cli.
-func convertDocumentToPDF(documentFilePath string) (string, error) {
-	panic("implement me")
+// fileURL returns the file URL of the path, e.g. "file:///tmp/profile" or "file:///C:/Temp/profile".
+func fileURL(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	return (&url.URL{Scheme: "file", Path: path}).String(), nil
+}
+
+// removeLockFile removes the lock file LibreOffice leaves next to a document it failed to convert.
+func removeLockFile(documentFilePath string) {
+	lockFile := filepath.Join(filepath.Dir(documentFilePath), ".~lock."+filepath.Base(documentFilePath)+"#")
+	os.Remove(lockFile)
 }
 
 // changeFileExtension changes the file extension to the new extension.
+// Files without an extension and hidden dotfiles get the new extension appended,
+// for multi-dot names only the last extension is replaced.
 func changeFileExtension(filePath string, newExtension string) string {
 	fileName := filepath.Base(filePath)
-	fileNameWithoutExt := fileName[:len(fileName)-len(filepath.Ext(fileName))]
-	newFileName := fileNameWithoutExt + "." + newExtension
+	extension := filepath.Ext(fileName)
+
+	// A leading dot marks a hidden file, not an extension
+	if extension == fileName {
+		extension = ""
+	}
+
+	fileNameWithoutExt := strings.TrimSuffix(fileName, extension)
+	newFileName := fileNameWithoutExt + "." + strings.TrimPrefix(newExtension, ".")
 	return filepath.Join(filepath.Dir(filePath), newFileName)
 }
 
 // IsStructEmpty a function to check if a struct is empty or not.
+// A struct is empty when every field holds the zero value of its type, a nil pointer is empty.
 func IsStructEmpty(data interface{}) bool {
 	v := reflect.ValueOf(data)
 
 	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return true
+		}
 		v = v.Elem()
 	}
 
 	for i := 0; i < v.NumField(); i++ {
-		field := v.Field(i)
-		if field.Kind() == reflect.String && field.String() != "" {
+		if !v.Field(i).IsZero() {
 			return false
 		}
 	}
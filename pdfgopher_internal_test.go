@@ -0,0 +1,1176 @@
+package pdfgopher
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/draw"
+)
+
+func TestStampScaleGroups(t *testing.T) {
+	a4 := pageSize{Width: 595.28, Height: 841.89}
+	a3 := pageSize{Width: 841.89, Height: 1190.55}
+	a6 := pageSize{Width: 297.64, Height: 419.53}
+
+	groups := stampScaleGroups([]pageSize{a4, a3, a6, a6}, nil, 125, 0.15)
+
+	if assert.Len(t, groups, 3) {
+		assert.Equal(t, "1", groups[0].Pages)
+		assert.Equal(t, "2", groups[1].Pages)
+		assert.Equal(t, "3,4", groups[2].Pages)
+
+		// The stamp width must be 15% of every page width
+		assert.InDelta(t, a4.Width*0.15, groups[0].Scale*125, 0.01)
+		assert.InDelta(t, a3.Width*0.15, groups[1].Scale*125, 0.01)
+		assert.InDelta(t, a6.Width*0.15, groups[2].Scale*125, 0.01)
+	}
+}
+
+func TestChangeFileExtension(t *testing.T) {
+	tests := []struct {
+		name     string
+		filePath string
+		want     string
+	}{
+		{name: "simple", filePath: "dir/image.png", want: "dir/image.pdf"},
+		{name: "no extension", filePath: "dir/image", want: "dir/image.pdf"},
+		{name: "dotfile", filePath: "dir/.bashrc", want: "dir/.bashrc.pdf"},
+		{name: "dotfile with extension", filePath: "dir/.hidden.png", want: "dir/.hidden.pdf"},
+		{name: "multi dot", filePath: "dir/scan.2023.01.jpg", want: "dir/scan.2023.01.pdf"},
+		{name: "trailing dot", filePath: "dir/image.", want: "dir/image.pdf"},
+		{name: "multi byte", filePath: "dir/résumé-日本.jpeg", want: "dir/résumé-日本.pdf"},
+		{name: "no directory", filePath: "image.png", want: "image.pdf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, changeFileExtension(tt.filePath, "pdf"))
+		})
+	}
+}
+
+func TestValidationFlags(t *testing.T) {
+	tests := []struct {
+		mode string
+		want string
+	}{
+		{mode: "relaxed", want: "--mode relaxed"},
+		{mode: "strict", want: "--mode strict"},
+		{mode: "quiet", want: "--mode relaxed --quiet"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			flags, err := validationFlags(tt.mode)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, flags)
+		})
+	}
+
+	_, err := validationFlags("lenient")
+	assert.Error(t, err)
+}
+
+func TestInkFraction(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	assert.Equal(t, 0.0, inkFraction(img))
+
+	// Paint the top half black
+	draw.Draw(img, image.Rect(0, 0, 10, 5), image.Black, image.Point{}, draw.Src)
+
+	assert.Equal(t, 0.5, inkFraction(img))
+}
+
+func TestCreateProcessedFile(t *testing.T) {
+	dir := t.TempDir()
+	imagePath := filepath.Join(t.TempDir(), "scan*.png")
+
+	// Files with the same name get their own output, also when created concurrently
+	paths := make([]string, 8)
+	var wg sync.WaitGroup
+	for i := range paths {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			var err error
+			paths[i], err = createProcessedFile(imagePath, dir)
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	unique := make(map[string]bool)
+	for _, path := range paths {
+		assert.Equal(t, dir, filepath.Dir(path))
+		assert.Regexp(t, `^process-scan-\d+\.pdf$`, filepath.Base(path))
+		unique[path] = true
+	}
+	assert.Len(t, unique, len(paths))
+
+	// Without a directory the file is created next to the input
+	path, err := createProcessedFile(imagePath, "")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Dir(imagePath), filepath.Dir(path))
+}
+
+func TestConvertImageToPDFTinyImage(t *testing.T) {
+	imagePath := filepath.Join(t.TempDir(), "pixel.png")
+	writePNG(t, imagePath, image.NewRGBA(image.Rect(0, 0, 1, 1)))
+
+	pdfPath, err := convertImageToPDF(imagePath, "", imageConversion{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Dir(imagePath), filepath.Dir(pdfPath))
+	assert.Regexp(t, `^process-pixel-\d+\.pdf$`, filepath.Base(pdfPath))
+	assert.FileExists(t, pdfPath)
+}
+
+func TestConvertImageToPDFZeroHeight(t *testing.T) {
+	imagePath := filepath.Join(t.TempDir(), "corrupt.png")
+	writePNG(t, imagePath, image.NewRGBA(image.Rect(0, 0, 1, 1)))
+
+	// Patch the IHDR height to zero
+	data, err := os.ReadFile(imagePath)
+	assert.NoError(t, err)
+	copy(data[20:24], []byte{0, 0, 0, 0})
+	assert.NoError(t, os.WriteFile(imagePath, data, 0644))
+
+	_, err = convertImageToPDF(imagePath, "", imageConversion{})
+
+	assert.Error(t, err)
+}
+
+// writePNG encodes img as a PNG file at filePath.
+func writePNG(t *testing.T, filePath string, img image.Image) {
+	t.Helper()
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	if err := png.Encode(file, img); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMedianFilterRemovesSpecks(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	// Sprinkle isolated dark pixels
+	for _, p := range []image.Point{{2, 3}, {7, 11}, {15, 4}, {18, 18}, {10, 10}} {
+		img.Set(p.X, p.Y, color.Black)
+	}
+	assert.Greater(t, inkFraction(img), 0.0)
+
+	filtered := medianFilter(img, 3)
+
+	assert.Equal(t, 0.0, inkFraction(filtered))
+}
+
+func TestConvertImageToPDFDespeckle(t *testing.T) {
+	imagePath := filepath.Join(t.TempDir(), "scan.png")
+	writePNG(t, imagePath, image.NewRGBA(image.Rect(0, 0, 10, 10)))
+
+	pdfPath, err := convertImageToPDF(imagePath, "", imageConversion{DespeckleSize: 3})
+	assert.NoError(t, err)
+	assert.FileExists(t, pdfPath)
+
+	_, err = convertImageToPDF(imagePath, "", imageConversion{DespeckleSize: 4})
+	assert.Error(t, err)
+}
+
+func TestOrientationPages(t *testing.T) {
+	portrait := pageSize{Width: 595.28, Height: 841.89}
+	landscape := pageSize{Width: 841.89, Height: 595.28}
+
+	portraitPages, landscapePages := orientationPages([]pageSize{portrait, landscape, portrait, landscape})
+
+	assert.Equal(t, []int{1, 3}, portraitPages)
+	assert.Equal(t, []int{2, 4}, landscapePages)
+}
+
+func TestProcessFileCacheDir(t *testing.T) {
+	dir := t.TempDir()
+	imagePath := filepath.Join(dir, "scan.png")
+	writePNG(t, imagePath, image.NewRGBA(image.Rect(0, 0, 10, 10)))
+
+	calls := 0
+	imageConverter = func(imageFilePath string, outputDir string, conversion imageConversion) (string, error) {
+		calls++
+		return convertImageToPDF(imageFilePath, outputDir, conversion)
+	}
+	defer func() { imageConverter = convertImageToPDF }()
+
+	for i := 0; i < 2; i++ {
+		processor := NewPDFGopher(imagePath,
+			WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+			WithCacheDir(filepath.Join(dir, "cache")),
+		)
+
+		assert.NoError(t, processor.ProcessFile())
+		assert.NotEmpty(t, processor.Base64Output)
+	}
+
+	// The second run reuses the cached conversion
+	assert.Equal(t, 1, calls)
+
+	// Only the complete entry is left in the cache
+	entries, err := os.ReadDir(filepath.Join(dir, "cache"))
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, ".pdf", filepath.Ext(entries[0].Name()))
+	}
+}
+
+func TestVerifyPageCount(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "pages.pdf")
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	pdf.AddPage()
+	assert.NoError(t, pdf.OutputFileAndClose(filePath))
+
+	assert.NoError(t, addQRCodeToPDF(context.Background(), filePath, "./sample_image/qr-generate.png", "br", 0))
+	assert.NoError(t, verifyPageCount(context.Background(), filePath, 2))
+
+	// A stamp that duplicated a page must be reported
+	assert.ErrorIs(t, verifyPageCount(context.Background(), filePath, 3), ErrPageCountChanged)
+}
+
+func TestConvertImageToPDFCompression(t *testing.T) {
+	dir := t.TempDir()
+	compressedPath := filepath.Join(dir, "compressed.png")
+	uncompressedPath := filepath.Join(dir, "uncompressed.png")
+	writePNG(t, compressedPath, image.NewRGBA(image.Rect(0, 0, 10, 10)))
+	writePNG(t, uncompressedPath, image.NewRGBA(image.Rect(0, 0, 10, 10)))
+
+	compressed, err := convertImageToPDF(compressedPath, "", imageConversion{})
+	assert.NoError(t, err)
+
+	uncompressed, err := convertImageToPDF(uncompressedPath, "", imageConversion{DisableCompression: true})
+	assert.NoError(t, err)
+
+	compressedData, err := os.ReadFile(compressed)
+	assert.NoError(t, err)
+	uncompressedData, err := os.ReadFile(uncompressed)
+	assert.NoError(t, err)
+
+	// The page content stream is only flate encoded when compression is enabled
+	assert.Equal(t, strings.Count(string(compressedData), "/FlateDecode")-1, strings.Count(string(uncompressedData), "/FlateDecode"))
+
+	// Only the page content stream loses its filter, the images keep the flate encoding of the PNG
+	// file, so for a small image the uncompressed file isn't necessarily larger
+	flateContent := regexp.MustCompile(`<</Filter /FlateDecode /Length \d+>>\nstream\n`)
+	assert.Regexp(t, flateContent, string(compressedData))
+	assert.NotRegexp(t, flateContent, string(uncompressedData))
+	assert.Regexp(t, `<</Length \d+>>\nstream\n0 J\n`, string(uncompressedData))
+
+	count, err := pageCount(context.Background(), uncompressed)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestQRGridPage(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "grid.pdf")
+	entries := []QREntry{
+		{Data: "https://example.com/1", Caption: "One"},
+		{Data: "https://example.com/2", Caption: "Two"},
+		{Data: "https://example.com/3", Caption: "Three"},
+		{Data: "https://example.com/4", Caption: "Four"},
+	}
+
+	err := QRGridPage(entries, 2, outputPath)
+	assert.NoError(t, err)
+
+	count, err := pageCount(context.Background(), outputPath)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	width, height := gofpdf.New("P", "mm", "A4", "").GetPageSize()
+	cells, err := gridCells(len(entries), 2, width, height)
+	assert.NoError(t, err)
+
+	rows := map[float64]bool{}
+	for _, cell := range cells {
+		assert.Equal(t, 1, cell.Page)
+		rows[cell.Y] = true
+	}
+	assert.Len(t, rows, 2)
+
+	// Entries beyond the rows of a page continue on the next page
+	cells, err = gridCells(6, 2, width, height)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, cells[len(cells)-1].Page)
+
+	assert.Error(t, QRGridPage(entries, 0, outputPath))
+}
+
+func TestConvertImageToPDFEXIFMetadata(t *testing.T) {
+	dir := t.TempDir()
+	photoPath := filepath.Join(dir, "photo.jpg")
+	writeEXIFJPEG(t, photoPath, "2021:03:04 05:06:07")
+
+	output, err := convertImageToPDF(photoPath, "", imageConversion{EXIFMetadata: true})
+	assert.NoError(t, err)
+
+	data, err := os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "/CreationDate (D:20210304050607)")
+
+	// Images without EXIF are converted as usual
+	plainPath := filepath.Join(dir, "plain.png")
+	writePNG(t, plainPath, image.NewRGBA(image.Rect(0, 0, 10, 10)))
+
+	_, err = convertImageToPDF(plainPath, "", imageConversion{EXIFMetadata: true})
+	assert.NoError(t, err)
+}
+
+// writeEXIFJPEG writes a small JPEG with an EXIF segment holding only DateTimeOriginal.
+func writeEXIFJPEG(t *testing.T, filePath string, dateTimeOriginal string) {
+	t.Helper()
+
+	var img bytes.Buffer
+	if err := jpeg.Encode(&img, image.NewRGBA(image.Rect(0, 0, 10, 10)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Little endian TIFF with IFD0 pointing to an Exif IFD holding DateTimeOriginal
+	le := binary.LittleEndian
+	tiff := []byte("II*\x00")
+	tiff = le.AppendUint32(tiff, 8)
+	tiff = le.AppendUint16(tiff, 1)
+	tiff = append(le.AppendUint16(le.AppendUint16(tiff, 0x8769), 4), 1, 0, 0, 0)
+	tiff = le.AppendUint32(le.AppendUint32(tiff, 26), 0)
+	tiff = le.AppendUint16(tiff, 1)
+	value := dateTimeOriginal + "\x00"
+	tiff = le.AppendUint32(le.AppendUint16(le.AppendUint16(tiff, 0x9003), 2), uint32(len(value)))
+	tiff = le.AppendUint32(le.AppendUint32(tiff, 44), 0)
+	tiff = append(tiff, value...)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := binary.BigEndian.AppendUint16([]byte{0xFF, 0xE1}, uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+
+	data := append(append(img.Bytes()[:2:2], app1...), img.Bytes()[2:]...)
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConvertImagesToPDFDPINormalization(t *testing.T) {
+	dir := t.TempDir()
+
+	// Both images cover one square inch, at 100 and 200 DPI
+	pngPath := filepath.Join(dir, "scan-100.png")
+	var pngData bytes.Buffer
+	assert.NoError(t, png.Encode(&pngData, image.NewRGBA(image.Rect(0, 0, 100, 100))))
+	phys := binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(nil, 3937), 3937)
+	assert.NoError(t, os.WriteFile(pngPath, insertPNGChunk(pngData.Bytes(), "pHYs", append(phys, 1)), 0644))
+
+	jpegPath := filepath.Join(dir, "scan-200.jpg")
+	var jpegData bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&jpegData, image.NewRGBA(image.Rect(0, 0, 200, 200)), nil))
+	jfif := append([]byte("JFIF\x00\x01\x02\x01"), 0, 200, 0, 200, 0, 0)
+	app0 := append(binary.BigEndian.AppendUint16([]byte{0xFF, 0xE0}, uint16(len(jfif)+2)), jfif...)
+	assert.NoError(t, os.WriteFile(jpegPath, append(append(jpegData.Bytes()[:2:2], app0...), jpegData.Bytes()[2:]...), 0644))
+
+	// Without normalization the pages follow the pixel size
+	outputPath := filepath.Join(dir, "pixels.pdf")
+	assert.NoError(t, ConvertImagesToPDF([]string{pngPath, jpegPath}, outputPath))
+
+	sizes, err := pageSizes(context.Background(), outputPath)
+	assert.NoError(t, err)
+	if assert.Len(t, sizes, 2) {
+		assert.InDelta(t, 100, sizes[0].Width, 0.01)
+		assert.InDelta(t, 200, sizes[1].Width, 0.01)
+	}
+
+	outputPath = filepath.Join(dir, "normalized.pdf")
+	assert.NoError(t, ConvertImagesToPDF([]string{pngPath, jpegPath}, outputPath, WithDPINormalization(150)))
+
+	sizes, err = pageSizes(context.Background(), outputPath)
+	assert.NoError(t, err)
+	if assert.Len(t, sizes, 2) {
+		for _, size := range sizes {
+			assert.InDelta(t, 72, size.Width, 0.01)
+			assert.InDelta(t, 72, size.Height, 0.01)
+		}
+	}
+
+	assert.Error(t, ConvertImagesToPDF([]string{pngPath}, outputPath, WithDPINormalization(-1)))
+	assert.Error(t, ConvertImagesToPDF(nil, outputPath))
+}
+
+// insertPNGChunk inserts a chunk right after the IHDR chunk of PNG data.
+func insertPNGChunk(data []byte, chunkType string, body []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(body)))
+	chunk = append(append(chunk, chunkType...), body...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+
+	// The signature and IHDR chunk take 33 bytes
+	return append(append(data[:33:33], chunk...), data[33:]...)
+}
+
+func TestParseFeatures(t *testing.T) {
+	help := `pdfcpu provides command-line tools for working with PDF files.
+
+Usage:
+  pdfcpu [command]
+
+Available Commands:
+  crop          Set cropbox for selected pages
+  info          Print file info
+  nup           Rearrange pages or images for reduced number of pages
+  stamp         Add, remove, update text, image or PDF stamps for selected pages
+
+Flags:
+  -h, --help            help for pdfcpu
+`
+	infoHelp := `Usage:
+  pdfcpu info inFile... [flags]
+
+Flags:
+  -j, --json           output JSON
+`
+
+	features := parseFeatures(help, infoHelp)
+	assert.Equal(t, map[string]bool{"crop": true, "info": true, "nup": true, "stamp": true, FeatureJSON: true}, features)
+	assert.False(t, features["help"])
+
+	// Older releases list the commands differently and lack JSON output
+	help = `Go-pdfcpu is a tool for PDF manipulation written in Go.
+
+Usage:
+
+	pdfcpu command [arguments]
+
+The commands are:
+
+   encrypt     set password
+   info        print file info
+   merge       concatenate 2 or more PDFs
+
+   Completion supported for all commands.
+`
+	infoHelp = `usage: pdfcpu info [-pages selectedPages] [-u] inFile`
+
+	features = parseFeatures(help, infoHelp)
+	assert.True(t, features["encrypt"])
+	assert.True(t, features["merge"])
+	assert.False(t, features["nup"])
+	assert.False(t, features[FeatureJSON])
+	assert.False(t, features["Completion"])
+}
+
+func TestConvertDocumentToPDF(t *testing.T) {
+	binDir := t.TempDir()
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	// A fake soffice, called as: soffice -env:UserInstallation=URL --headless --convert-to pdf --outdir DIR FILE
+	profilesPath := filepath.Join(t.TempDir(), "profiles")
+	script := `#!/bin/sh
+echo "$1" >> '` + profilesPath + `'
+case "$7" in
+*broken*)
+	touch "$(dirname "$7")/.~lock.$(basename "$7")#"
+	echo "source file could not be loaded" >&2
+	exit 1
+	;;
+esac
+name=$(basename "$7")
+printf '%%PDF-1.4\n' > "$6/${name%.*}.pdf"
+`
+	assert.NoError(t, os.WriteFile(filepath.Join(binDir, "soffice"), []byte(script), 0755))
+
+	dir := t.TempDir()
+	documentPath := filepath.Join(dir, "report.docx")
+	assert.NoError(t, os.WriteFile(documentPath, []byte("document"), 0644))
+
+	output, err := convertDocumentToPDF(context.Background(), documentPath, "")
+	assert.NoError(t, err)
+	assert.Equal(t, dir, filepath.Dir(output))
+	assert.Regexp(t, `^process-report-\d+\.pdf$`, filepath.Base(output))
+	assert.FileExists(t, output)
+
+	// Any format LibreOffice opens converts the same way
+	spreadsheetPath := filepath.Join(dir, "budget.xlsx")
+	assert.NoError(t, os.WriteFile(spreadsheetPath, []byte("spreadsheet"), 0644))
+
+	output, err = convertDocumentToPDF(context.Background(), spreadsheetPath, "")
+	assert.NoError(t, err)
+	assert.Equal(t, dir, filepath.Dir(output))
+	assert.Regexp(t, `^process-budget-\d+\.pdf$`, filepath.Base(output))
+
+	brokenPath := filepath.Join(dir, "broken.doc")
+	assert.NoError(t, os.WriteFile(brokenPath, []byte("document"), 0644))
+
+	_, err = convertDocumentToPDF(context.Background(), brokenPath, "")
+	assert.ErrorContains(t, err, "could not be loaded")
+	assert.NoFileExists(t, filepath.Join(dir, ".~lock.broken.doc#"))
+
+	// Every conversion runs with a profile of its own
+	data, err := os.ReadFile(profilesPath)
+	assert.NoError(t, err)
+	profiles := strings.Fields(string(data))
+	if assert.Len(t, profiles, 3) {
+		for _, profile := range profiles {
+			assert.Regexp(t, `^-env:UserInstallation=file:///.+/soffice-\d+/profile$`, profile)
+		}
+		assert.NotEqual(t, profiles[0], profiles[1])
+	}
+}
+
+func TestConvertDocumentToPDFWithoutLibreOffice(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	_, err := convertDocumentToPDF(context.Background(), filepath.Join(t.TempDir(), "report.docx"), "")
+	assert.ErrorContains(t, err, "soffice not found")
+}
+
+func TestPagesToImagesWorkerPool(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "pages.pdf")
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	for i := 0; i < 12; i++ {
+		pdf.AddPage()
+	}
+	assert.NoError(t, pdf.OutputFileAndClose(filePath))
+
+	var running, peak int32
+	rasterizer := pageRasterizer
+	defer func() { pageRasterizer = rasterizer }()
+	pageRasterizer = func(filePath string, page int, dpi int, output string) error {
+		current := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+
+		for {
+			max := atomic.LoadInt32(&peak)
+			if current <= max || atomic.CompareAndSwapInt32(&peak, max, current) {
+				break
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		return os.WriteFile(output, []byte(strconv.Itoa(page)), 0644)
+	}
+
+	outputDir := filepath.Join(t.TempDir(), "images")
+	pages, err := PagesToImages(filePath, outputDir, WithRenderConcurrency(3))
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, peak, int32(3))
+
+	if assert.Len(t, pages, 12) {
+		for i, page := range pages {
+			assert.Equal(t, filepath.Join(outputDir, fmt.Sprintf("page-%02d.png", i+1)), page)
+
+			data, err := os.ReadFile(page)
+			assert.NoError(t, err)
+			assert.Equal(t, strconv.Itoa(i+1), string(data))
+		}
+	}
+
+	_, err = PagesToImages(filePath, outputDir, WithRenderConcurrency(0))
+	assert.Error(t, err)
+}
+
+func TestProcessFileDocumentOutputPath(t *testing.T) {
+	samplePath, err := filepath.Abs("./sample_pdf/process-tree-736885__480.pdf")
+	assert.NoError(t, err)
+
+	// A fake soffice producing the sample PDF
+	binDir := t.TempDir()
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	script := "#!/bin/sh\nname=$(basename \"$7\")\ncp '" + samplePath + "' \"$6/${name%.*}.pdf\"\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(binDir, "soffice"), []byte(script), 0755))
+
+	documentPath := filepath.Join(t.TempDir(), "report.docx")
+	assert.NoError(t, os.WriteFile(documentPath, []byte("document"), 0644))
+
+	processor := NewPDFGopher(documentPath,
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+		WithoutBase64(),
+	)
+	assert.NoError(t, processor.ProcessFile())
+
+	assert.Equal(t, filepath.Dir(documentPath), filepath.Dir(processor.OutputPath))
+	assert.Regexp(t, `^process-report-\d+\.pdf$`, filepath.Base(processor.OutputPath))
+	assert.FileExists(t, processor.OutputPath)
+}
+
+func TestParsePageSelection(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     []int
+	}{
+		{selector: "even,odd", want: []int{1, 2, 3, 4, 5}},
+		{selector: "1", want: []int{1}},
+		{selector: "l", want: []int{5}},
+		{selector: "l-1", want: []int{4}},
+		{selector: "l-4", want: []int{1}},
+		{selector: "1-3,5", want: []int{1, 2, 3, 5}},
+		{selector: "4-", want: []int{4, 5}},
+		{selector: "-2", want: []int{1, 2}},
+		{selector: "even", want: []int{2, 4}},
+		{selector: "!2", want: []int{1, 3, 4, 5}},
+		{selector: "1-l,n3", want: []int{1, 2, 4, 5}},
+		{selector: "7", want: []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			pages, err := parsePageSelection(tt.selector, 5)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, pages)
+		})
+	}
+
+	for _, selector := range []string{"", " ", "1,,3", "0", "a-3", "l-x", "l-5", "l-7"} {
+		_, err := parsePageSelection(selector, 5)
+		assert.Error(t, err, selector)
+	}
+
+	_, err := parsePageSelection("l-5", 5)
+	assert.EqualError(t, err, `invalid page selector "l-5": term l-5 is before the first of 5 pages`)
+
+	// Options are validated before the page count is known
+	_, err = parsePageSelection("l-5", 0)
+	assert.NoError(t, err)
+}
+
+func TestIncrementalUpdateXRefTable(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "table.pdf")
+
+	// gofpdf writes a cross-reference table and compressed page content
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Helvetica", "", 12)
+	pdf.AddPage()
+	pdf.Cell(0, 10, "before")
+	assert.NoError(t, pdf.OutputFileAndClose(filePath))
+
+	data, err := os.ReadFile(filePath)
+	assert.NoError(t, err)
+
+	var updated []pdfStream
+	for _, stream := range flateStreams(data) {
+		if bytes.Contains(stream.Content, []byte("(before)")) {
+			stream.Content = bytes.Replace(stream.Content, []byte("(before)"), []byte("(after)"), 1)
+			updated = append(updated, stream)
+		}
+	}
+	if !assert.Len(t, updated, 1) {
+		return
+	}
+
+	update, err := incrementalUpdate(data, updated)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filePath, append(data, update...), 0644))
+
+	// Readers must pick up the new version of the content stream
+	assert.NoError(t, exec.Command("pdfcpu", "validate", filePath).Run())
+
+	dir := t.TempDir()
+	assert.NoError(t, exec.Command("pdfcpu", "extract", "--mode", "content", filePath, dir).Run())
+
+	contents, err := filepath.Glob(filepath.Join(dir, "*"))
+	assert.NoError(t, err)
+	if assert.Len(t, contents, 1) {
+		content, err := os.ReadFile(contents[0])
+		assert.NoError(t, err)
+		assert.Contains(t, string(content), "(after)")
+	}
+}
+
+func TestPDFTextString(t *testing.T) {
+	assert.Equal(t, `(QR \(a\\b\))`, pdfTextString(`QR (a\b)`))
+	assert.Equal(t, "<FEFF00E9>", pdfTextString("é"))
+}
+
+func TestConvertImageToPDFPageSize(t *testing.T) {
+	tests := []struct {
+		name       string
+		size       string
+		orient     string
+		image      image.Rectangle
+		wantWidth  float64
+		wantHeight float64
+	}{
+		{name: "default", image: image.Rect(0, 0, 40, 10), wantWidth: 595.28, wantHeight: 841.89},
+		{name: "letter", size: "Letter", image: image.Rect(0, 0, 10, 10), wantWidth: 612, wantHeight: 792},
+		{name: "legal landscape", size: "legal", orient: "L", image: image.Rect(0, 0, 10, 10), wantWidth: 1008, wantHeight: 612},
+		{name: "auto wide", size: "Letter", orient: "auto", image: image.Rect(0, 0, 40, 10), wantWidth: 792, wantHeight: 612},
+		{name: "auto tall", size: "A4", orient: "auto", image: image.Rect(0, 0, 10, 40), wantWidth: 595.28, wantHeight: 841.89},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			imagePath := filepath.Join(t.TempDir(), "scan.png")
+			writePNG(t, imagePath, image.NewRGBA(tt.image))
+
+			pdfPath, err := convertImageToPDF(imagePath, "", imageConversion{PageSize: tt.size, Orientation: tt.orient})
+			assert.NoError(t, err)
+
+			sizes, err := pageSizes(context.Background(), pdfPath)
+			if assert.NoError(t, err) && assert.Len(t, sizes, 1) {
+				assert.InDelta(t, tt.wantWidth, sizes[0].Width, 0.01)
+				assert.InDelta(t, tt.wantHeight, sizes[0].Height, 0.01)
+			}
+		})
+	}
+
+	imagePath := filepath.Join(t.TempDir(), "scan.png")
+	writePNG(t, imagePath, image.NewRGBA(image.Rect(0, 0, 10, 10)))
+
+	_, err := convertImageToPDF(imagePath, "", imageConversion{PageSize: "B5"})
+	assert.EqualError(t, err, "invalid page size: B5, expected one of A3, A4, A5, Letter, Legal")
+
+	_, err = convertImageToPDF(imagePath, "", imageConversion{Orientation: "sideways"})
+	assert.EqualError(t, err, "invalid page orientation: sideways")
+}
+
+func TestConvertImageToPDFImageDPI(t *testing.T) {
+	// placement returns the size in points and the pixel width of the image embedded in the PDF file
+	placement := func(t *testing.T, pdfPath string) (float64, float64, int) {
+		data, err := os.ReadFile(pdfPath)
+		assert.NoError(t, err)
+
+		size := regexp.MustCompile(`q ([\d.]+) 0 0 ([\d.]+) [\d.]+ [\d.]+ cm /I`).FindSubmatch(data)
+		pixels := regexp.MustCompile(`/Subtype /Image\s*/Width (\d+)`).FindSubmatch(data)
+		if !assert.NotNil(t, size) || !assert.NotNil(t, pixels) {
+			return 0, 0, 0
+		}
+
+		width, _ := strconv.ParseFloat(string(size[1]), 64)
+		height, _ := strconv.ParseFloat(string(size[2]), 64)
+		pixelWidth, _ := strconv.Atoi(string(pixels[1]))
+		return width, height, pixelWidth
+	}
+
+	dir := t.TempDir()
+
+	// A 2x1 inch receipt at 150 DPI keeps its printed size
+	receiptPath := filepath.Join(dir, "receipt.png")
+	writePNG(t, receiptPath, image.NewRGBA(image.Rect(0, 0, 300, 150)))
+
+	pdfPath, err := convertImageToPDF(receiptPath, "", imageConversion{ImageDPI: 150, DisableCompression: true})
+	if assert.NoError(t, err) {
+		width, height, pixelWidth := placement(t, pdfPath)
+		assert.InDelta(t, 144, width, 0.01)
+		assert.InDelta(t, 72, height, 0.01)
+		assert.Equal(t, 300, pixelWidth)
+	}
+
+	// A photo wider than the page spans the page width, downsampled to 72 DPI
+	photoPath := filepath.Join(dir, "photo.jpg")
+	photo, err := os.Create(photoPath)
+	assert.NoError(t, err)
+	assert.NoError(t, jpeg.Encode(photo, image.NewRGBA(image.Rect(0, 0, 2000, 1000)), nil))
+	assert.NoError(t, photo.Close())
+
+	pdfPath, err = convertImageToPDF(photoPath, "", imageConversion{ImageDPI: 72, DisableCompression: true})
+	if assert.NoError(t, err) {
+		width, _, pixelWidth := placement(t, pdfPath)
+		assert.InDelta(t, 595.28, width, 0.01)
+		assert.Equal(t, 595, pixelWidth)
+	}
+
+	_, err = convertImageToPDF(receiptPath, "", imageConversion{ImageDPI: -1})
+	assert.EqualError(t, err, "invalid image DPI: -1")
+}
+
+func TestConvertImageToPDFJPEGQuality(t *testing.T) {
+	dir := t.TempDir()
+
+	// A noisy photo, which compresses poorly at a high quality
+	photo := image.NewRGBA(image.Rect(0, 0, 400, 300))
+	for i := range photo.Pix {
+		photo.Pix[i] = byte(i * 7919 % 251)
+	}
+
+	var sizes []int64
+	for _, quality := range []int{0, 30} {
+		photoPath := filepath.Join(dir, fmt.Sprintf("photo-%d.jpg", quality))
+		file, err := os.Create(photoPath)
+		assert.NoError(t, err)
+		assert.NoError(t, jpeg.Encode(file, photo, &jpeg.Options{Quality: 95}))
+		assert.NoError(t, file.Close())
+
+		pdfPath, err := convertImageToPDF(photoPath, "", imageConversion{JPEGQuality: quality})
+		if assert.NoError(t, err) {
+			info, err := os.Stat(pdfPath)
+			assert.NoError(t, err)
+			sizes = append(sizes, info.Size())
+		}
+	}
+
+	if assert.Len(t, sizes, 2) {
+		assert.Less(t, sizes[1], sizes[0])
+	}
+
+	_, err := convertImageToPDF(filepath.Join(dir, "photo-0.jpg"), "", imageConversion{JPEGQuality: 101})
+	assert.EqualError(t, err, "invalid JPEG quality: 101")
+}
+
+func TestGenerateQRCodeWithOversizedIcon(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "qr.png")
+	icon := "./sample_image/privyid-favicon.png"
+
+	_, err := GenerateQRCodeWithIcon("https://example.com", icon, filePath, WithIconSize(200), WithErrorCorrection(ErrorCorrectionH))
+	assert.EqualError(t, err, "icon size 200 exceeds QR code size 125")
+
+	// The clear zone counts towards the icon size
+	_, err = GenerateQRCodeWithIcon("https://example.com", icon, filePath, WithIconSize(100), WithIconClearZone(20, 0))
+	assert.EqualError(t, err, "icon size 140 exceeds QR code size 125")
+
+	assert.NoFileExists(t, filePath)
+}
+
+func TestGenerateQRCodeWithoutIcon(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "qr.png")
+
+	qrCode, err := GenerateQRCode("https://example.com/doc/42", filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, filePath, qrCode)
+
+	img, err := decodeImageFile(qrCode)
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 125, 125), img.Bounds())
+
+	data, err := decodeQRCode(img)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/doc/42", data)
+
+	_, err = GenerateQRCode("https://example.com", filePath, WithCollisionPolicy(CollisionError))
+	assert.Error(t, err)
+}
+
+func TestGenerateQRCodeWithIconClearZone(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "qr.png")
+
+	qrCode, err := GenerateQRCodeWithIcon("https://example.com/doc/42", "./sample_image/privyid-favicon.png", filePath, WithIconClearZone(3, 6))
+	assert.NoError(t, err)
+
+	img, err := decodeImageFile(qrCode)
+	assert.NoError(t, err)
+
+	// The zone around the 30x30 icon centered in the 125x125 code is white
+	for _, point := range []image.Point{{46, 62}, {78, 62}, {62, 46}, {62, 78}} {
+		r, g, b, _ := img.At(point.X, point.Y).RGBA()
+		assert.Equal(t, [3]uint32{0xffff, 0xffff, 0xffff}, [3]uint32{r, g, b}, point)
+	}
+
+	data, err := decodeQRCode(img)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/doc/42", data)
+
+	_, err = GenerateQRCodeWithIcon("https://example.com", "./sample_image/privyid-favicon.png", filePath, WithIconClearZone(-1, 0))
+	assert.EqualError(t, err, "invalid icon clear zone: padding -1, radius 0")
+}
+
+func TestRoundedRect(t *testing.T) {
+	mask := roundedRect{rect: image.Rect(0, 0, 20, 20), radius: 5}
+
+	assert.Equal(t, color.Transparent, mask.At(0, 0))
+	assert.Equal(t, color.Opaque, mask.At(5, 0))
+	assert.Equal(t, color.Opaque, mask.At(10, 10))
+	assert.Equal(t, color.Transparent, mask.At(19, 19))
+	assert.Equal(t, color.Transparent, mask.At(20, 10))
+}
+
+func TestPDFCPUCommandConfigDir(t *testing.T) {
+	cmd := pdfcpuCommand(context.Background(), "version")
+	assert.Equal(t, []string{"pdfcpu", "version"}, cmd.Args)
+
+	dir := t.TempDir()
+	cmd = pdfcpuCommand(withPDFCPUConfigDir(context.Background(), dir), "version")
+	assert.Equal(t, []string{"pdfcpu", "--conf", dir, "version"}, cmd.Args)
+
+	// pdfcpu creates its configuration in dir
+	assert.NoError(t, cmd.Run())
+	assert.FileExists(t, filepath.Join(dir, "pdfcpu", "config.yml"))
+}
+
+func TestRunPDFCPUError(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.pdf")
+
+	err := encrypted(context.Background(), missing, "secret", "", "--key=128")
+
+	var pdfcpuErr *PDFCPUError
+	if assert.ErrorAs(t, err, &pdfcpuErr) {
+		assert.Equal(t, "pdfcpu encrypt --upw *** --opw *** --key=128 "+missing, pdfcpuErr.Cmd)
+		assert.NotEmpty(t, pdfcpuErr.Stderr)
+		assert.Equal(t, 1, exitCode(err))
+		assert.Equal(t, "error executing pdfcpu command: exit status 1: "+pdfcpuErr.Stderr, err.Error())
+		assert.NotContains(t, err.Error(), "secret")
+	}
+
+	assert.Equal(t, "pdfcpu validate --upw=*** file.pdf", pdfcpuCommandLine([]string{"validate", "--upw=secret", "file.pdf"}))
+	assert.Equal(t, -1, exitCode(errors.New("not started")))
+}
+
+func TestRunPDFCPUWithoutShell(t *testing.T) {
+	binDir := t.TempDir()
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	// A fake pdfcpu writing each argument on its own line
+	argsPath := filepath.Join(binDir, "args")
+	script := "#!/bin/sh\nprintf '%s\\n' \"$@\" > '" + argsPath + "'\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(binDir, "pdfcpu"), []byte(script), 0755))
+
+	injected := filepath.Join(binDir, "injected")
+	password := "pass word; touch " + injected + " $(touch " + injected + ")"
+
+	assert.NoError(t, decrypted(context.Background(), "file.pdf", password))
+
+	args, err := os.ReadFile(argsPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "decrypt\n--upw\n"+password+"\nfile.pdf\n", string(args))
+	assert.NoFileExists(t, injected)
+}
+
+func TestRunPDFCPUDryRun(t *testing.T) {
+	var commands bytes.Buffer
+	ctx := context.WithValue(context.Background(), dryRunKey{}, io.Writer(&commands))
+
+	missing := filepath.Join(t.TempDir(), "missing.pdf")
+
+	// Commands changing a file are only written
+	output, err := runPDFCPU(ctx, "decrypt", "--upw", "it's secret", missing)
+	assert.NoError(t, err)
+	assert.Nil(t, output)
+	assert.Equal(t, "pdfcpu decrypt --upw *** "+missing+"\n", commands.String())
+
+	// Commands reading a file still run
+	_, err = runPDFCPU(ctx, "info", missing)
+	assert.Error(t, err)
+
+	assert.True(t, readOnlyPDFCPUCommand([]string{"keywords", "list", "file.pdf"}))
+	assert.True(t, readOnlyPDFCPUCommand([]string{"--conf", "dir", "config", "list"}))
+	assert.False(t, readOnlyPDFCPUCommand([]string{"--conf", "dir", "optimize", "in.pdf", "out.pdf"}))
+
+	assert.Equal(t, "'pos:br, rot:0'", shellQuote("pos:br, rot:0"))
+	assert.Equal(t, `'it'\''s'`, shellQuote("it's"))
+	assert.Equal(t, "''", shellQuote(""))
+	assert.Equal(t, "1-3,5", shellQuote("1-3,5"))
+}
+
+func TestHasPDFPassword(t *testing.T) {
+	ctx := context.Background()
+
+	protected, err := hasPDFPassword(ctx, "./sample_pdf/soal_no_3_protected_protected.pdf", "relaxed")
+	assert.NoError(t, err)
+	assert.True(t, protected)
+
+	protected, err = hasPDFPassword(ctx, "./sample_pdf/process-tree-736885__480.pdf", "relaxed")
+	assert.NoError(t, err)
+	assert.False(t, protected)
+
+	// A corrupt file fails validation without asking for a password
+	corrupt := filepath.Join(t.TempDir(), "corrupt.pdf")
+	assert.NoError(t, os.WriteFile(corrupt, []byte("%PDF-1.7\ngarbage\n"), 0644))
+
+	protected, err = hasPDFPassword(ctx, corrupt, "relaxed")
+	assert.False(t, protected)
+
+	var pdfcpuErr *PDFCPUError
+	if assert.ErrorAs(t, err, &pdfcpuErr) {
+		assert.NotContains(t, pdfcpuErr.Stderr, passwordPrompt)
+	}
+}
+
+func TestStampAddArgs(t *testing.T) {
+	spec := StampSpec{extraFlags: []string{"--unit=mm", "-q"}}
+
+	args := stampAddArgs(spec, "--pages", "1", "--mode", "image", "--", "qr.png", "pos:br", "file.pdf")
+	assert.Equal(t, []string{"stamp", "add", "--unit=mm", "-q", "--pages", "1", "--mode", "image", "--", "qr.png", "pos:br", "file.pdf"}, args)
+
+	assert.NoError(t, validateExtraFlags(spec.extraFlags))
+	assert.NoError(t, validateExtraFlags(nil))
+
+	for _, flag := range []string{"other.pdf", "--", "-", ""} {
+		assert.EqualError(t, validateExtraFlags([]string{"--offline", flag}), fmt.Sprintf("invalid extra flag %q: flags must start with a dash and attach their value with =", flag))
+	}
+}
+
+func TestStampDescription(t *testing.T) {
+	spec := StampSpec{Position: BottomRight, Rotation: 45}
+	assert.Equal(t, "pos:br, rot:45, scale:0.1000, op:1", stampDescription(spec, "0.1000"))
+
+	spec.OffsetX, spec.OffsetY = -10, 12.5
+	assert.Equal(t, "pos:br, rot:45, scale:0.1000, op:1, offset:-10 12.5", stampDescription(spec, "0.1000"))
+
+	spec.OffsetY = math.NaN()
+	assert.EqualError(t, validateStampSpec(spec), "invalid stamp offset: -10 NaN")
+}
+
+func TestTextStampArgs(t *testing.T) {
+	args := textStampArgs("CONFIDENTIAL", TopRight, "1,3", "file.pdf")
+	assert.Equal(t, []string{"stamp", "add", "--pages", "1,3", "--mode", "text", "--", "CONFIDENTIAL", "pos:tr, rot:0, scale:0.5 rel, fillcolor:#808080, op:0.6", "file.pdf"}, args)
+
+	args = textStampArgs("DRAFT", "", "even,odd", "file.pdf")
+	assert.Equal(t, "pos:c, rot:0, scale:0.5 rel, fillcolor:#808080, op:0.6", args[8])
+}
+
+func TestGenerateQRCodeWithIconSize(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "qr.png")
+	icon := "./sample_image/privyid-favicon.png"
+
+	qrCode, err := GenerateQRCodeWithIcon("https://example.com/doc/42", icon, filePath, WithQRSize(300), WithIconSize(120), WithErrorCorrection(ErrorCorrectionH))
+	assert.NoError(t, err)
+
+	img, err := decodeImageFile(qrCode)
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 300, 300), img.Bounds())
+
+	data, err := decodeQRCode(img)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/doc/42", data)
+
+	// Medium correction can't recover an icon that large
+	_, err = GenerateQRCodeWithIcon("https://example.com/doc/42", icon, filePath, WithQRSize(300), WithIconSize(120))
+	assert.EqualError(t, err, "icon covers 16.0% of the QR code, error correction level M recovers 15%")
+
+	// The clear zone counts as covered
+	_, err = GenerateQRCodeWithIcon("https://example.com/doc/42", icon, filePath, WithIconClearZone(10, 0))
+	assert.EqualError(t, err, "icon covers 16.0% of the QR code, error correction level M recovers 15%")
+
+	_, err = GenerateQRCodeWithIcon("https://example.com", icon, filePath, WithQRSize(0))
+	assert.EqualError(t, err, "invalid QR code size: 0")
+
+	_, err = GenerateQRCodeWithIcon("https://example.com", icon, filePath, WithIconSize(0))
+	assert.EqualError(t, err, "invalid icon size: 0")
+
+	_, err = GenerateQRCode("https://example.com", filePath, WithErrorCorrection("X"))
+	assert.EqualError(t, err, `invalid error correction level: "X"`)
+}
+
+func TestPDFCPUPermissions(t *testing.T) {
+	assert.Equal(t, "x000", pdfcpuPermissions(Permissions{}))
+	assert.Equal(t, "x804", pdfcpuPermissions(Permissions{Print: true}))
+	assert.Equal(t, "xF3C", pdfcpuPermissions(Permissions{Print: true, Copy: true, Modify: true, Annotate: true}))
+}
+
+func TestProcessStreamRemovesTempFiles(t *testing.T) {
+	input, err := os.ReadFile("./sample_image/tree-736885__480.jpg")
+	assert.NoError(t, err)
+
+	tempDir := t.TempDir()
+
+	var output bytes.Buffer
+	err = processStream(bytes.NewReader(input), &output,
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+		WithTempDir(tempDir),
+	)
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(output.Bytes(), []byte("%PDF-")))
+
+	// The converted image is removed along with the buffered input
+	entries, err := os.ReadDir(tempDir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+// writeIncrementalPDF writes a PDF file made of the revisions, every revision being a list of
+// objects appended with its own cross-reference section and trailer.
+func writeIncrementalPDF(t *testing.T, filePath string, revisions ...[]string) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n")
+
+	objectHeader := regexp.MustCompile(`^(\d+) (\d+) obj`)
+	size, prev := 1, 0
+	for _, objects := range revisions {
+		var xref strings.Builder
+		for _, object := range objects {
+			match := objectHeader.FindStringSubmatch(object)
+			number, _ := strconv.Atoi(match[1])
+			generation, _ := strconv.Atoi(match[2])
+			if number >= size {
+				size = number + 1
+			}
+
+			fmt.Fprintf(&xref, "%d 1\n%010d %05d n \n", number, buf.Len(), generation)
+			buf.WriteString(object + "\n")
+		}
+
+		offset := buf.Len()
+		trailer := fmt.Sprintf("/Size %d /Root 1 0 R", size)
+		if prev == 0 {
+			fmt.Fprintf(&buf, "xref\n0 1\n0000000000 65535 f \n%s", xref.String())
+		} else {
+			fmt.Fprintf(&buf, "xref\n%s", xref.String())
+			trailer += fmt.Sprintf(" /Prev %d", prev)
+		}
+		fmt.Fprintf(&buf, "trailer\n<< %s >>\nstartxref\n%d\n%%%%EOF\n", trailer, offset)
+		prev = offset
+	}
+
+	assert.NoError(t, os.WriteFile(filePath, buf.Bytes(), 0644))
+
+	return buf.String()
+}
+
+func TestFindSignatureFieldIncrementalUpdate(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "updated.pdf")
+
+	data := writeIncrementalPDF(t, filePath,
+		[]string{
+			"1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj",
+			"2 0 obj\n<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 >>\nendobj",
+			"3 0 obj\n<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Annots [5 0 R] >>\nendobj",
+			"4 0 obj\n<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>\nendobj",
+			"5 0 obj\n<< /Type /Annot /Subtype /Widget /FT /Sig /T (Old) /Rect [10 10 20 20] /P 3 0 R >>\nendobj",
+		},
+		// The update moves the field to the second page, reusing object 5 with generation 1
+		[]string{
+			"3 0 obj\n<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>\nendobj",
+			"4 0 obj\n<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Annots [5 1 R] >>\nendobj",
+			"5 1 obj\n<< /Type /Annot /Subtype /Widget /TU (Sign the stream of pages) /FT /Sig /T (New) /Rect [400 50 550 100] /P 4 0 R >>\nendobj",
+		},
+	)
+
+	objects := parseObjects(data)
+	assert.NotContains(t, objects["3 0"], "/Annots")
+	assert.Contains(t, objects["5 0"], "(Old)")
+	assert.Contains(t, objects["5 1"], "/Rect [400 50 550 100]")
+
+	page, rect, err := findSignatureField(data)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, page)
+	assert.Equal(t, Rect{LLX: 400, LLY: 50, URX: 550, URY: 100}, rect)
+
+	page, rect, err = FindSignatureField(filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, page)
+	assert.Equal(t, Rect{LLX: 400, LLY: 50, URX: 550, URY: 100}, rect)
+}
@@ -1,16 +1,32 @@
 package pdfgopher_test
 
 import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	. "github.com/RamdhaniMichan/PDFGopher"
 
+	"github.com/jung-kurt/gofpdf"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestProcessPDF(t *testing.T) {
-	pdfProcess := NewPDFGopher("./sample_pdf/process-tree-736885__480.pdf",
+	pdfProcess := NewPDFGopher(copyFile(t, "./sample_pdf/process-tree-736885__480.pdf"),
 		WithOptionMetadataPDF(OptionMetadataPDF{Title: "Me to", Author: "Me to", Subject: "Me to"}),
 		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png", StampPosition: "tl"}),
 	)
@@ -31,3 +47,1315 @@ func TestGenerateQRCode(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotEmpty(t, qrCode)
 }
+
+func TestProcessPDFRelativeStampWidth(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "mixed.pdf")
+
+	// Create a document with A4, A3 and A6 pages
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	pdf.AddPageFormat("P", gofpdf.SizeType{Wd: 297, Ht: 420})
+	pdf.AddPageFormat("P", gofpdf.SizeType{Wd: 105, Ht: 148})
+	assert.NoError(t, pdf.OutputFileAndClose(filePath))
+
+	pdfProcess := NewPDFGopher(filePath,
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png", StampWidthRatio: 0.15}),
+	)
+
+	err := pdfProcess.ProcessFile()
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, pdfProcess.Base64Output)
+}
+
+func TestProcessPDFWithoutBase64(t *testing.T) {
+	filePath := copyFile(t, "./sample_pdf/process-tree-736885__480.pdf")
+
+	pdfProcess := NewPDFGopher(filePath,
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+		WithoutBase64(),
+	)
+
+	err := pdfProcess.ProcessFile()
+
+	assert.NoError(t, err)
+	assert.Empty(t, pdfProcess.Base64Output)
+	assert.Equal(t, filepath.Dir(filePath), filepath.Dir(pdfProcess.OutputPath))
+	assert.Regexp(t, `^process-process-tree-736885__480-\d+\.pdf$`, filepath.Base(pdfProcess.OutputPath))
+	assert.FileExists(t, pdfProcess.OutputPath)
+
+	// Running again keeps the previous output
+	previous := pdfProcess.OutputPath
+	pdfProcess.Reset(filePath)
+	assert.NoError(t, pdfProcess.ProcessFile())
+	assert.NotEqual(t, previous, pdfProcess.OutputPath)
+	assert.FileExists(t, previous)
+}
+
+func TestProcessPDFMissingQRCode(t *testing.T) {
+	qrCode := filepath.Join(t.TempDir(), "missing-qr.png")
+
+	pdfProcess := NewPDFGopher(copyFile(t, "./sample_pdf/process-tree-736885__480.pdf"),
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: qrCode}),
+		WithoutBase64(),
+	)
+
+	err := pdfProcess.ProcessFile()
+	assert.EqualError(t, err, "file not found: "+qrCode)
+}
+
+func TestProcessPDFFailureKeepsOriginal(t *testing.T) {
+	filePath := copyFile(t, "./sample_pdf/soal_no_3_protected_protected.pdf")
+	original, err := os.ReadFile(filePath)
+	assert.NoError(t, err)
+
+	for _, policy := range []WritePolicy{InPlace, CopyOnWrite} {
+		// Stamping fails after the file has been decrypted
+		pdfProcess := NewPDFGopher(filePath,
+			WithOptionFilePDF(OptionFilePDF{PasswordPDF: "12345", QRCodePath: filepath.Join(t.TempDir(), "missing-qr.png")}),
+			WithWritePolicy(policy),
+			WithoutBase64(),
+		)
+
+		assert.Error(t, pdfProcess.ProcessFile())
+		assert.Empty(t, pdfProcess.OutputPath)
+
+		// The original is untouched and no decrypted copy is left behind
+		data, err := os.ReadFile(filePath)
+		assert.NoError(t, err)
+		assert.Equal(t, original, data)
+
+		entries, err := os.ReadDir(filepath.Dir(filePath))
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+	}
+}
+
+func TestWithOptionFilePDFMerge(t *testing.T) {
+	pdfProcess := NewPDFGopher("file.pdf",
+		WithOptionFilePDF(OptionFilePDF{StampScale: 0.3, StampOpacity: 0.5}),
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "qr.png", StampRotation: 45}),
+	)
+
+	// Zero fields of a later value don't reset earlier values or the defaults
+	assert.Equal(t, OptionFilePDF{
+		QRCodePath:    "qr.png",
+		StampPosition: BottomRight,
+		StampScale:    0.3,
+		StampRotation: 45,
+		StampOpacity:  0.5,
+		StampPages:    "even,odd",
+	}, *pdfProcess.OptionFilePDF)
+}
+
+func TestIsStructEmpty(t *testing.T) {
+	assert.True(t, IsStructEmpty(OptionMetadataPDF{}))
+	assert.True(t, IsStructEmpty(&OptionFilePDF{}))
+	assert.True(t, IsStructEmpty((*OptionMetadataPDF)(nil)))
+
+	assert.False(t, IsStructEmpty(&OptionMetadataPDF{Author: "Gopher"}))
+	assert.False(t, IsStructEmpty(OptionFilePDF{StampScale: 0.2}))
+	assert.False(t, IsStructEmpty(OptionFilePDF{StampOpacity: 0.5, StampPages: "1"}))
+
+	type mixed struct {
+		Name    string
+		Count   int
+		Enabled bool
+		Tags    []string
+	}
+	assert.True(t, IsStructEmpty(mixed{}))
+	assert.False(t, IsStructEmpty(mixed{Count: 3}))
+	assert.False(t, IsStructEmpty(mixed{Enabled: true}))
+	assert.False(t, IsStructEmpty(mixed{Tags: []string{}}))
+}
+
+func TestProcessPDFMetadataFields(t *testing.T) {
+	pdfProcess := NewPDFGopher(copyFile(t, "./sample_pdf/process-tree-736885__480.pdf"),
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+		WithOptionMetadataPDF(OptionMetadataPDF{
+			Title:    "Report",
+			Author:   "Gopher",
+			Subject:  "Audit",
+			Keywords: "finance, q3 , audit",
+			Creator:  "Scanner",
+		}),
+		WithoutBase64(),
+	)
+	assert.NoError(t, pdfProcess.ProcessFile())
+
+	info := readInfo(t, pdfProcess.OutputPath)
+	assert.Equal(t, "Report", info.Title)
+	assert.Equal(t, "Gopher", info.Author)
+	assert.Equal(t, "Audit", info.Subject)
+	assert.ElementsMatch(t, []string{"finance", "q3", "audit"}, info.Keywords)
+	assert.Equal(t, "Scanner", info.Creator)
+
+	// Empty fields keep the existing values
+	partial := NewPDFGopher(pdfProcess.OutputPath,
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+		WithOptionMetadataPDF(OptionMetadataPDF{Author: "Reviewer", Keywords: "reviewed"}),
+		WithoutBase64(),
+	)
+	assert.NoError(t, partial.ProcessFile())
+
+	info = readInfo(t, partial.OutputPath)
+	assert.Equal(t, "Report", info.Title)
+	assert.Equal(t, "Reviewer", info.Author)
+	assert.Equal(t, "Audit", info.Subject)
+	assert.Equal(t, []string{"reviewed"}, info.Keywords)
+	assert.Equal(t, "Scanner", info.Creator)
+}
+
+func TestClearMetadataField(t *testing.T) {
+	filePath := copyFile(t, "./sample_pdf/process-tree-736885__480.pdf")
+
+	err := exec.Command("pdfcpu", "properties", "add", filePath, "Title = Report", "Author = Gopher", "Subject = Audit").Run()
+	assert.NoError(t, err)
+
+	assert.NoError(t, ClearMetadataField(filePath, "Author"))
+
+	info := readInfo(t, filePath)
+	assert.Empty(t, info.Author)
+	assert.Equal(t, "Report", info.Title)
+	assert.Equal(t, "Audit", info.Subject)
+
+	assert.EqualError(t, ClearMetadataField(filePath, "Colour"), "unknown metadata field: Colour, expected one of Title, Author, Subject")
+
+	// pdfcpu failures keep the command and its error output
+	err = ClearMetadataField(filepath.Join(t.TempDir(), "missing.pdf"), "Author")
+
+	var pdfcpuErr *PDFCPUError
+	if assert.ErrorAs(t, err, &pdfcpuErr) {
+		assert.Contains(t, pdfcpuErr.Cmd, "pdfcpu properties remove")
+		assert.NotEmpty(t, pdfcpuErr.Stderr)
+	}
+}
+
+func TestProcessPDFStampPages(t *testing.T) {
+	pdfProcess := NewPDFGopher(multiPagePDF(t, 3),
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png", StampPages: "1,l"}),
+		WithoutBase64(),
+	)
+	assert.NoError(t, pdfProcess.ProcessFile())
+
+	pages := pageStreams(t, pdfProcess.OutputPath)
+	if assert.Len(t, pages, 3) {
+		assert.Contains(t, pages[0], "/Watermark")
+		assert.NotContains(t, pages[1], "/Watermark")
+		assert.Contains(t, pages[2], "/Watermark")
+	}
+
+	pdfProcess = NewPDFGopher(multiPagePDF(t, 3),
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png", StampPages: "1,,3"}),
+		WithoutBase64(),
+	)
+	assert.EqualError(t, pdfProcess.ProcessFile(), `invalid page selector "1,,3": empty term`)
+}
+
+func TestProcessPDFShellMetacharacters(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "signed contracts")
+	assert.NoError(t, os.Mkdir(dir, 0755))
+
+	// Neither the paths nor the password may be parsed by a shell
+	filePath := filepath.Join(dir, "contract 'final' $(touch injected).pdf")
+	qrCode := filepath.Join(dir, "qr code; echo.png")
+	// pdfcpu rejects spaces in AES-256 passwords
+	password := `it's;"secret"$HOME`
+
+	data, err := os.ReadFile("./sample_pdf/process-tree-736885__480.pdf")
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filePath, data, 0644))
+
+	data, err = os.ReadFile("./sample_image/qr-generate.png")
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(qrCode, data, 0644))
+
+	err = exec.Command("pdfcpu", "encrypt", "--upw", password, "--opw", password, filePath).Run()
+	assert.NoError(t, err)
+
+	pdfProcess := NewPDFGopher(filePath,
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: qrCode, PasswordPDF: password}),
+		WithOptionMetadataPDF(OptionMetadataPDF{Title: "Tom's contract", Author: "O'Brien", Subject: "$(id)"}),
+		WithoutBase64(),
+	)
+	assert.NoError(t, pdfProcess.ProcessFile())
+
+	info := readInfo(t, pdfProcess.OutputPath, "--upw", password)
+	assert.True(t, info.Encrypted)
+	assert.Equal(t, "Tom's contract", info.Title)
+	assert.Equal(t, "O'Brien", info.Author)
+	assert.Equal(t, "$(id)", info.Subject)
+	assert.NoFileExists(t, "injected")
+}
+
+// copyFile copies a sample file into a temporary directory so tests don't modify the original.
+func copyFile(t *testing.T, src string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(t.TempDir(), filepath.Base(src))
+	if err := os.WriteFile(dst, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return dst
+}
+
+func TestOutputSize(t *testing.T) {
+	pdfProcess := NewPDFGopher(copyFile(t, "./sample_pdf/process-tree-736885__480.pdf"),
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+	)
+
+	_, err := pdfProcess.OutputSize()
+	assert.Error(t, err)
+
+	assert.NoError(t, pdfProcess.ProcessFile())
+
+	size, err := pdfProcess.OutputSize()
+	assert.NoError(t, err)
+
+	decoded, err := base64.StdEncoding.DecodeString(pdfProcess.Base64Output)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(decoded)), size)
+}
+
+func TestClose(t *testing.T) {
+	imagePath := copyFile(t, "./sample_image/tree-736885__480.jpg")
+
+	pdfProcess := NewPDFGopher(imagePath,
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+		WithoutBase64(),
+	)
+	assert.NoError(t, pdfProcess.ProcessFile())
+
+	outputPath := pdfProcess.OutputPath
+	assert.FileExists(t, outputPath)
+
+	assert.NoError(t, pdfProcess.Close())
+	assert.NoFileExists(t, outputPath)
+	assert.FileExists(t, imagePath)
+	assert.Empty(t, pdfProcess.OutputPath)
+
+	// Closing twice is harmless
+	assert.NoError(t, pdfProcess.Close())
+
+	// Files processed in place are kept
+	filePath := copyFile(t, "./sample_pdf/process-tree-736885__480.pdf")
+
+	pdfProcess = NewPDFGopher(filePath,
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+		WithWritePolicy(InPlace),
+		WithoutBase64(),
+	)
+	assert.NoError(t, pdfProcess.ProcessFile())
+	assert.NoError(t, pdfProcess.Close())
+	assert.FileExists(t, filePath)
+}
+
+func TestWithPDFCPUConfigDir(t *testing.T) {
+	// The user configuration stays untouched
+	userConfig := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", userConfig)
+
+	pdfProcess := NewPDFGopher(copyFile(t, "./sample_pdf/process-tree-736885__480.pdf"),
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+		WithoutBase64(),
+	)
+	assert.NoError(t, pdfProcess.ProcessFile())
+	assert.NoDirExists(t, filepath.Join(userConfig, "pdfcpu"))
+
+	configDir := t.TempDir()
+	pdfProcess = NewPDFGopher(copyFile(t, "./sample_pdf/process-tree-736885__480.pdf"),
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+		WithPDFCPUConfigDir(configDir),
+		WithoutBase64(),
+	)
+	assert.NoError(t, pdfProcess.ProcessFile())
+	assert.FileExists(t, filepath.Join(configDir, "pdfcpu", "config.yml"))
+	assert.NoDirExists(t, filepath.Join(userConfig, "pdfcpu"))
+}
+
+func TestProcessPDFExtraFlags(t *testing.T) {
+	filePath := copyFile(t, "./sample_pdf/soal_no_3_protected_protected.pdf")
+
+	pdfProcess := NewPDFGopher(filePath,
+		WithOptionFilePDF(OptionFilePDF{PasswordPDF: "12345", QRCodePath: "./sample_image/qr-generate.png"}),
+		WithExtraStampFlags([]string{"--unit=mm"}),
+		WithExtraEncryptFlags([]string{"--key=128"}),
+		WithExtraValidateFlags([]string{"--offline"}),
+		WithoutBase64(),
+	)
+	assert.NoError(t, pdfProcess.ProcessFile())
+
+	info, err := EncryptionInfo(pdfProcess.OutputPath, "12345")
+	assert.NoError(t, err)
+	assert.Equal(t, 128, info.KeyLength)
+
+	// Operands can't be smuggled in as flags
+	pdfProcess = NewPDFGopher(filePath,
+		WithOptionFilePDF(OptionFilePDF{PasswordPDF: "12345", QRCodePath: "./sample_image/qr-generate.png"}),
+		WithExtraEncryptFlags([]string{"--key", "128"}),
+	)
+	assert.EqualError(t, pdfProcess.ProcessFile(), `invalid extra flag "128": flags must start with a dash and attach their value with =`)
+}
+
+func TestProcessPDFEncryptWhen(t *testing.T) {
+	binDir := t.TempDir()
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	// A fake pdftotext that finds the text in the uncompressed content stream
+	script := `#!/bin/sh
+grep -ao 'SSN [0-9-]*\|Public notice' "$6"
+exit 0
+`
+	assert.NoError(t, os.WriteFile(filepath.Join(binDir, "pdftotext"), []byte(script), 0755))
+
+	ssn := regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`)
+
+	for text, sensitive := range map[string]bool{"SSN 123-45-6789": true, "Public notice": false} {
+		filePath := filepath.Join(t.TempDir(), "document.pdf")
+
+		pdf := gofpdf.New("P", "mm", "A4", "")
+		pdf.SetCompression(false)
+		pdf.SetFont("Helvetica", "", 12)
+		pdf.AddPage()
+		pdf.Cell(0, 10, text)
+		assert.NoError(t, pdf.OutputFileAndClose(filePath))
+
+		pdfProcess := NewPDFGopher(filePath,
+			WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png", PasswordPDF: "secret"}),
+			WithEncryptWhen(ssn.MatchString),
+			WithoutBase64(),
+		)
+		assert.NoError(t, pdfProcess.ProcessFile())
+		assert.Equal(t, sensitive, pdfProcess.PDFProtection, text)
+
+		info := readInfo(t, pdfProcess.OutputPath, "--upw", "secret")
+		assert.Equal(t, sensitive, info.Encrypted, text)
+	}
+
+	// A hanging pdftotext is killed when the context is done
+	script = "#!/bin/sh\nexec sleep 300\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(binDir, "pdftotext"), []byte(script), 0755))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := NewPDFGopher(copyFile(t, "./sample_pdf/process-tree-736885__480.pdf"),
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png", PasswordPDF: "secret"}),
+		WithEncryptWhen(ssn.MatchString),
+		WithoutBase64(),
+	).ProcessFileContext(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
+
+func TestReset(t *testing.T) {
+	protectedPath := copyFile(t, "./sample_pdf/soal_no_3_protected_protected.pdf")
+	plainPath := copyFile(t, "./sample_pdf/process-tree-736885__480.pdf")
+
+	pdfProcess := NewPDFGopher(protectedPath,
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png", PasswordPDF: "12345"}),
+		WithOptionMetadataPDF(OptionMetadataPDF{Title: "Reused", Author: "Gopher", Subject: "Reset"}),
+	)
+	defer pdfProcess.Close()
+
+	assert.NoError(t, pdfProcess.ProcessFile())
+	assert.True(t, pdfProcess.PDFProtection)
+	firstOutput, firstBase64 := pdfProcess.OutputPath, pdfProcess.Base64Output
+
+	pdfProcess.Reset(plainPath)
+	assert.Equal(t, plainPath, pdfProcess.FilePath)
+	assert.Empty(t, pdfProcess.OutputPath)
+	assert.Empty(t, pdfProcess.Base64Output)
+	assert.False(t, pdfProcess.PDFProtection)
+
+	// The options carry over, the results don't
+	assert.NoError(t, pdfProcess.ProcessFile())
+	assert.False(t, pdfProcess.PDFProtection)
+	assert.NotEqual(t, firstOutput, pdfProcess.OutputPath)
+	assert.NotEqual(t, firstBase64, pdfProcess.Base64Output)
+
+	info := readInfo(t, pdfProcess.OutputPath)
+	assert.False(t, info.Encrypted)
+	assert.Equal(t, "Reused", info.Title)
+	assert.FileExists(t, firstOutput)
+}
+
+func TestProcessPDFSourceFilename(t *testing.T) {
+	filePath := copyFile(t, "./sample_pdf/process-tree-736885__480.pdf")
+
+	pdfProcess := NewPDFGopher(filePath,
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+		WithSourceFilename(),
+		WithJobID("job-42"),
+	)
+
+	assert.NoError(t, pdfProcess.ProcessFile())
+
+	out, err := exec.Command("pdfcpu", "properties", "list", pdfProcess.OutputPath).Output()
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), "SourceFilename = process-tree-736885__480.pdf")
+	assert.Contains(t, string(out), "JobID = job-42")
+}
+
+func TestProcessPDFOrientationStamps(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "orientation.pdf")
+
+	// Create a document with a portrait and a landscape page
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	pdf.AddPageFormat("L", gofpdf.SizeType{Wd: 210, Ht: 297})
+	assert.NoError(t, pdf.OutputFileAndClose(filePath))
+
+	pdfProcess := NewPDFGopher(filePath,
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+		WithOrientationStamps(StampSpec{Position: "br"}, StampSpec{Position: "tl"}),
+	)
+
+	err := pdfProcess.ProcessFile()
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, pdfProcess.Base64Output)
+}
+
+func TestProcessPDFWithGeneratedQR(t *testing.T) {
+	filePath := copyFile(t, "./sample_pdf/process-tree-736885__480.pdf")
+
+	pdfProcess := NewPDFGopher(filePath,
+		WithGeneratedQR("https://google.com", "./sample_image/privyid-favicon.png"),
+	)
+
+	err := pdfProcess.ProcessFile()
+
+	assert.NoError(t, err)
+	assert.True(t, readInfo(t, pdfProcess.OutputPath).Watermarked)
+}
+
+// pdfInfo represents the part of the pdfcpu info JSON output checked by tests.
+type pdfInfo struct {
+	PageCount   int      `json:"pageCount"`
+	Title       string   `json:"title"`
+	Author      string   `json:"author"`
+	Subject     string   `json:"subject"`
+	Keywords    []string `json:"keywords"`
+	Creator     string   `json:"creator"`
+	Watermarked bool     `json:"watermarked"`
+	Encrypted   bool     `json:"encrypted"`
+	// PageBoundaries is only listed for the pages selected with --pages.
+	PageBoundaries map[string]struct {
+		MediaBox struct {
+			Rect struct {
+				UR struct{ X, Y float64 } `json:"ur"`
+			} `json:"rect"`
+		} `json:"mediaBox"`
+		Rotation int `json:"rot"`
+	} `json:"pageBoundaries"`
+}
+
+// readInfo reads the info of the PDF file with pdfcpu, extra arguments such as passwords are passed through.
+func readInfo(t *testing.T, filePath string, args ...string) pdfInfo {
+	t.Helper()
+
+	out, err := exec.Command("pdfcpu", append(append([]string{"info", "--json"}, args...), filePath)...).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var info struct {
+		Infos []pdfInfo `json:"infos"`
+	}
+	if err := json.Unmarshal(out, &info); err != nil || len(info.Infos) == 0 {
+		t.Fatalf("invalid pdfcpu info output: %s", out)
+	}
+
+	return info.Infos[0]
+}
+
+func TestProcessPDFExistingStamps(t *testing.T) {
+	// The sample PDF already carries QR code stamps next to its page image
+	stampedPath := copyFile(t, "./sample_pdf/process-tree-736885__480.pdf")
+	assert.True(t, readInfo(t, stampedPath).Watermarked)
+
+	images := countImages(t, stampedPath)
+	assert.Greater(t, images, 2)
+
+	tests := []struct {
+		policy StampPolicy
+		images int
+	}{
+		{StampLayer, images + 1},
+		{StampPreserve, images},
+		{StampReplace, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			filePath := copyFile(t, stampedPath)
+
+			// Stamp a different image, identical images are shared by pdfcpu
+			pdfProcess := NewPDFGopher(filePath,
+				WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/privyid-favicon.png"}),
+				WithExistingStamps(tt.policy),
+				WithoutBase64(),
+			)
+
+			err := pdfProcess.ProcessFile()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.images, countImages(t, pdfProcess.OutputPath))
+		})
+	}
+
+	pdfProcess := NewPDFGopher(copyFile(t, stampedPath), WithExistingStamps("unknown"), WithoutBase64())
+	assert.Error(t, pdfProcess.ProcessFile())
+}
+
+// countImages returns the number of images in the PDF file as listed by pdfcpu.
+func countImages(t *testing.T, filePath string) int {
+	t.Helper()
+
+	out, err := exec.Command("pdfcpu", "images", "list", filePath).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var count int
+	match := regexp.MustCompile(`(\d+) images? available`).FindSubmatch(out)
+	if match == nil {
+		t.Fatalf("invalid pdfcpu images output: %s", out)
+	}
+	fmt.Sscan(string(match[1]), &count)
+
+	return count
+}
+
+func TestGenerateQRCodeCollisionPolicy(t *testing.T) {
+	iconPath := "./sample_image/privyid-favicon.png"
+	filePath := filepath.Join(t.TempDir(), "qr-generate.png")
+	assert.NoError(t, os.WriteFile(filePath, []byte("existing"), 0644))
+
+	t.Run("overwrite", func(t *testing.T) {
+		qrCode, err := GenerateQRCodeWithIcon("https://google.com", iconPath, filePath)
+		assert.NoError(t, err)
+		assert.Equal(t, filePath, qrCode)
+
+		data, err := os.ReadFile(filePath)
+		assert.NoError(t, err)
+		assert.NotEqual(t, "existing", string(data))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := GenerateQRCodeWithIcon("https://google.com", iconPath, filePath, WithCollisionPolicy(CollisionError))
+		assert.ErrorIs(t, err, os.ErrExist)
+
+		freePath := filepath.Join(filepath.Dir(filePath), "qr-free.png")
+		qrCode, err := GenerateQRCodeWithIcon("https://google.com", iconPath, freePath, WithCollisionPolicy(CollisionError))
+		assert.NoError(t, err)
+		assert.Equal(t, freePath, qrCode)
+	})
+
+	t.Run("suffix", func(t *testing.T) {
+		dir := filepath.Dir(filePath)
+
+		qrCode, err := GenerateQRCodeWithIcon("https://google.com", iconPath, filePath, WithCollisionPolicy(CollisionSuffix))
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "qr-generate-1.png"), qrCode)
+
+		qrCode, err = GenerateQRCodeWithIcon("https://google.com", iconPath, filePath, WithCollisionPolicy(CollisionSuffix))
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "qr-generate-2.png"), qrCode)
+	})
+
+	_, err := GenerateQRCodeWithIcon("https://google.com", iconPath, filePath, WithCollisionPolicy("unknown"))
+	assert.Error(t, err)
+}
+
+func TestBase64Chunks(t *testing.T) {
+	pdfProcess := NewPDFGopher(copyFile(t, "./sample_pdf/process-tree-736885__480.pdf"),
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+	)
+
+	err := pdfProcess.ProcessFile()
+	assert.NoError(t, err)
+
+	chunks := pdfProcess.Base64Chunks(1000)
+	assert.Len(t, chunks, (len(pdfProcess.Base64Output)+999)/1000)
+	for _, chunk := range chunks[:len(chunks)-1] {
+		assert.Len(t, chunk, 1000)
+	}
+	assert.Equal(t, pdfProcess.Base64Output, JoinBase64Chunks(chunks))
+
+	assert.Nil(t, pdfProcess.Base64Chunks(0))
+	assert.Nil(t, pdfProcess.Base64Chunks(-1))
+}
+
+func TestStampPosition(t *testing.T) {
+	anchors := map[StampPosition]string{
+		TopLeft:      "tl",
+		TopCenter:    "tc",
+		TopRight:     "tr",
+		Left:         "l",
+		Center:       "c",
+		Right:        "r",
+		BottomLeft:   "bl",
+		BottomCenter: "bc",
+		BottomRight:  "br",
+	}
+
+	for position, anchor := range anchors {
+		assert.Equal(t, anchor, position.String())
+	}
+
+	assert.Equal(t, BottomRight, NewPDFGopher("file.pdf").StampPosition)
+	assert.Equal(t, TopLeft, NewPDFGopher("file.pdf", WithStampPosition("tl")).StampPosition)
+	assert.Equal(t, Center, NewPDFGopher("file.pdf", WithOptionFilePDF(OptionFilePDF{StampPosition: Center})).StampPosition)
+}
+
+func TestProcessPDFWritePolicy(t *testing.T) {
+	// A protected input goes through decryption, stamping, metadata and encryption
+	filePath := copyFile(t, "./sample_pdf/process-tree-736885__480.pdf")
+	err := exec.Command("pdfcpu", "encrypt", "--upw", "secret", "--opw", "secret", filePath).Run()
+	assert.NoError(t, err)
+
+	original, err := os.ReadFile(filePath)
+	assert.NoError(t, err)
+
+	options := []Option{
+		WithOptionFilePDF(OptionFilePDF{PasswordPDF: "secret", QRCodePath: "./sample_image/qr-generate.png"}),
+		WithOptionMetadataPDF(OptionMetadataPDF{Title: "Copy", Author: "Me", Subject: "Write policy"}),
+		WithoutBase64(),
+	}
+
+	pdfProcess := NewPDFGopher(filePath, options...)
+	assert.NoError(t, pdfProcess.ProcessFile())
+	assert.NotEqual(t, filePath, pdfProcess.OutputPath)
+
+	// The input is preserved byte for byte
+	data, err := os.ReadFile(filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, original, data)
+
+	info := readInfo(t, pdfProcess.OutputPath, "--upw", "secret")
+	assert.Equal(t, "Copy", info.Title)
+	assert.True(t, info.Encrypted)
+
+	pdfProcess = NewPDFGopher(filePath, append(options, WithWritePolicy(InPlace))...)
+	assert.NoError(t, pdfProcess.ProcessFile())
+	assert.Equal(t, filePath, pdfProcess.OutputPath)
+
+	data, err = os.ReadFile(filePath)
+	assert.NoError(t, err)
+	assert.NotEqual(t, original, data)
+
+	pdfProcess = NewPDFGopher(filePath, WithWritePolicy("unknown"), WithoutBase64())
+	assert.Error(t, pdfProcess.ProcessFile())
+}
+
+func TestSupportedFeatures(t *testing.T) {
+	features, err := SupportedFeatures()
+
+	assert.NoError(t, err)
+	assert.True(t, features[FeatureJSON])
+	assert.True(t, features["stamp"])
+	assert.True(t, features["encrypt"])
+}
+
+func TestWriteTo(t *testing.T) {
+	filePath := copyFile(t, "./sample_image/tree-736885__480.jpg")
+
+	pdfProcess := NewPDFGopher(filePath,
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+	)
+
+	_, err := pdfProcess.WriteTo(io.Discard)
+	assert.Error(t, err)
+
+	assert.NoError(t, pdfProcess.ProcessFile())
+
+	// The output is the converted and stamped PDF, not the input image
+	assert.Equal(t, filepath.Dir(filePath), filepath.Dir(pdfProcess.OutputPath))
+	assert.Regexp(t, `^process-tree-736885__480-\d+\.pdf$`, filepath.Base(pdfProcess.OutputPath))
+
+	var buf bytes.Buffer
+	n, err := pdfProcess.WriteTo(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+
+	decoded, err := base64.StdEncoding.DecodeString(pdfProcess.Base64Output)
+	assert.NoError(t, err)
+	assert.Equal(t, decoded, buf.Bytes())
+}
+
+func TestProcessPDFTimestampFooter(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "footer.pdf")
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	pdf.AddPage()
+	assert.NoError(t, pdf.OutputFileAndClose(filePath))
+
+	pdfProcess := NewPDFGopher(filePath,
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+		WithTimestampFooter(),
+		WithJobID("job-42"),
+		WithoutBase64(),
+	)
+	assert.NoError(t, pdfProcess.ProcessFile())
+
+	footer := regexp.MustCompile(`\(Processed \d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2} UTC \| Job job-42\) Tj`)
+
+	pages := pageStreams(t, pdfProcess.OutputPath)
+	if assert.Len(t, pages, 2) {
+		for _, page := range pages {
+			assert.Regexp(t, footer, page)
+		}
+	}
+}
+
+func TestProcessPDFTextStamp(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "watermark.pdf")
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	for i := 0; i < 3; i++ {
+		pdf.AddPage()
+	}
+	assert.NoError(t, pdf.OutputFileAndClose(filePath))
+
+	pdfProcess := NewPDFGopher(filePath,
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png", StampPages: "1,3"}),
+		WithTextStamp("CONFIDENTIAL", "tc"),
+		WithoutBase64(),
+	)
+	assert.NoError(t, pdfProcess.ProcessFile())
+
+	pages := pageStreams(t, pdfProcess.OutputPath)
+	if assert.Len(t, pages, 3) {
+		assert.Contains(t, pages[0], "(CONFIDENTIAL) Tj")
+		assert.Contains(t, pages[0], "Do")
+		assert.NotContains(t, pages[1], "CONFIDENTIAL")
+		assert.Contains(t, pages[2], "(CONFIDENTIAL) Tj")
+	}
+
+	// Without a QR code only the text is stamped
+	textOnly := NewPDFGopher(filePath, WithTextStamp("DRAFT", ""), WithoutBase64())
+	assert.NoError(t, textOnly.ProcessFile())
+
+	pages = pageStreams(t, textOnly.OutputPath)
+	if assert.Len(t, pages, 3) {
+		for _, page := range pages {
+			assert.Contains(t, page, "(DRAFT) Tj")
+		}
+	}
+}
+
+func TestProcessPDFStampOffset(t *testing.T) {
+	// stampOrigin returns the translation of the newest stamp on the first page
+	stampOrigin := func(offsetX, offsetY float64) (float64, float64) {
+		pdfProcess := NewPDFGopher(copyFile(t, "./sample_pdf/process-tree-736885__480.pdf"),
+			WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png", StampOffsetX: offsetX, StampOffsetY: offsetY}),
+			WithoutBase64(),
+		)
+		assert.NoError(t, pdfProcess.ProcessFile())
+
+		matches := regexp.MustCompile(`(-?[\d.]+) (-?[\d.]+) cm /GS\d+ gs /Fm\d+ Do`).FindAllStringSubmatch(pageStreams(t, pdfProcess.OutputPath)[0], -1)
+		if !assert.NotEmpty(t, matches) {
+			return 0, 0
+		}
+
+		match := matches[len(matches)-1]
+
+		x, _ := strconv.ParseFloat(match[1], 64)
+		y, _ := strconv.ParseFloat(match[2], 64)
+		return x, y
+	}
+
+	x, y := stampOrigin(0, 0)
+	offsetX, offsetY := stampOrigin(-10, 10)
+	assert.InDelta(t, x-10, offsetX, 0.01)
+	assert.InDelta(t, y+10, offsetY, 0.01)
+
+	pdfProcess := NewPDFGopher(copyFile(t, "./sample_pdf/process-tree-736885__480.pdf"),
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png", StampOffsetX: math.Inf(1)}),
+		WithoutBase64(),
+	)
+	assert.EqualError(t, pdfProcess.ProcessFile(), "invalid stamp offset: +Inf 0")
+}
+
+func TestProcessFileDryRun(t *testing.T) {
+	input := copyFile(t, "./sample_pdf/process-tree-736885__480.pdf")
+	original, err := os.ReadFile(input)
+	assert.NoError(t, err)
+
+	var commands bytes.Buffer
+	pdfProcess := NewPDFGopher(input,
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png", StampPages: "1"}),
+		WithOptionMetadataPDF(OptionMetadataPDF{Title: "Dry run's title"}),
+		WithDryRun(&commands),
+		WithoutBase64(),
+	)
+	assert.NoError(t, pdfProcess.ProcessFile())
+
+	lines := strings.Split(strings.TrimSpace(commands.String()), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.Regexp(t, `^pdfcpu stamp add --pages 1 --mode image -- ./sample_image/qr-generate.png 'pos:br, rot:0, scale:0.1000, op:1' \S+\.pdf$`, lines[0])
+		assert.Regexp(t, `^pdfcpu properties add \S+\.pdf 'Title = Dry run'\\''s title'$`, lines[1])
+	}
+
+	// Neither the input nor its copy are changed
+	for _, filePath := range []string{input, pdfProcess.OutputPath} {
+		data, err := os.ReadFile(filePath)
+		assert.NoError(t, err)
+		assert.Equal(t, original, data)
+	}
+}
+
+func TestProcessFileDryRunAltText(t *testing.T) {
+	input := multiPagePDF(t, 1)
+	original, err := os.ReadFile(input)
+	assert.NoError(t, err)
+
+	var commands bytes.Buffer
+	pdfProcess := NewPDFGopher(input,
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png", StampAltText: "Verification QR code"}),
+		WithDryRun(&commands),
+		WithoutBase64(),
+	)
+	assert.NoError(t, pdfProcess.ProcessFile())
+
+	lines := strings.Split(strings.TrimSpace(commands.String()), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.Regexp(t, `^pdfcpu stamp add .* \S+\.pdf$`, lines[0])
+		assert.Regexp(t, `^# add alt text 'Verification QR code' to the new stamps of \S+\.pdf$`, lines[1])
+	}
+
+	data, err := os.ReadFile(pdfProcess.OutputPath)
+	assert.NoError(t, err)
+	assert.Equal(t, original, data)
+}
+
+func TestProcessPDFStampAppearance(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "appearance.pdf")
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	assert.NoError(t, pdf.OutputFileAndClose(filePath))
+
+	pdfProcess := NewPDFGopher(filePath,
+		WithOptionFilePDF(OptionFilePDF{
+			QRCodePath:    "./sample_image/qr-generate.png",
+			StampScale:    0.3,
+			StampRotation: 45,
+			StampOpacity:  0.5,
+		}),
+		WithoutBase64(),
+	)
+	assert.NoError(t, pdfProcess.ProcessFile())
+
+	pages := pageStreams(t, pdfProcess.OutputPath)
+	if assert.Len(t, pages, 1) {
+		// The QR code takes 30% of the A4 page width
+		match := regexp.MustCompile(`([\d.]+) 0 0 [\d.]+ 0 0 cm /Im\d+ Do`).FindStringSubmatch(pages[0])
+		if assert.NotNil(t, match) {
+			width, _ := strconv.ParseFloat(match[1], 64)
+			assert.InDelta(t, 0.3*595.28, width, 0.1)
+		}
+
+		assert.Contains(t, pages[0], "0.70711 0.70711 -0.70711 0.70711")
+		assert.Regexp(t, `/ca 0\.5`, pages[0])
+	}
+}
+
+func TestProcessPDFInvalidStampAppearance(t *testing.T) {
+	tests := []struct {
+		name    string
+		options OptionFilePDF
+		err     string
+	}{
+		{"negative scale", OptionFilePDF{StampScale: -0.1}, "invalid stamp scale: -0.10"},
+		{"opacity above one", OptionFilePDF{StampOpacity: 1.5}, "invalid stamp opacity: 1.50"},
+		{"negative opacity", OptionFilePDF{StampOpacity: -0.5}, "invalid stamp opacity: -0.50"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := copyFile(t, "./sample_pdf/process-tree-736885__480.pdf")
+
+			tt.options.QRCodePath = "./sample_image/qr-generate.png"
+			pdfProcess := NewPDFGopher(filePath, WithOptionFilePDF(tt.options), WithoutBase64())
+
+			assert.EqualError(t, pdfProcess.ProcessFile(), tt.err)
+			assert.Empty(t, pdfProcess.OutputPath)
+		})
+	}
+}
+
+// pageStreams splits the PDF file into single pages and returns the inflated streams of every page.
+func pageStreams(t *testing.T, filePath string) []string {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := exec.Command("pdfcpu", "split", filePath, dir).Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	pageFiles, err := filepath.Glob(filepath.Join(dir, "*.pdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// pdfcpu numbers the page files without padding
+	pageNumber := func(pageFile string) int {
+		match := regexp.MustCompile(`_(\d+)\.pdf$`).FindStringSubmatch(pageFile)
+		if match == nil {
+			return 0
+		}
+		number, _ := strconv.Atoi(match[1])
+		return number
+	}
+	sort.Slice(pageFiles, func(i, j int) bool { return pageNumber(pageFiles[i]) < pageNumber(pageFiles[j]) })
+
+	streamPattern := regexp.MustCompile(`(?s)stream\r?\n(.*?)endstream`)
+
+	var pages []string
+	for _, pageFile := range pageFiles {
+		data, err := os.ReadFile(pageFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var streams strings.Builder
+		for _, match := range streamPattern.FindAllSubmatch(data, -1) {
+			reader, err := zlib.NewReader(bytes.NewReader(match[1]))
+			if err != nil {
+				// Not a flate stream, such as a JPEG image
+				continue
+			}
+
+			inflated, _ := io.ReadAll(reader)
+			streams.Write(inflated)
+		}
+
+		pages = append(pages, streams.String())
+	}
+
+	return pages
+}
+
+func TestProcessPDFBase64Output(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		pdfProcess := NewPDFGopher(copyFile(t, "./sample_pdf/process-tree-736885__480.pdf"),
+			WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+			WithBase64Output(enabled),
+		)
+		assert.NoError(t, pdfProcess.ProcessFile())
+
+		if !enabled {
+			assert.Empty(t, pdfProcess.Base64Output)
+			continue
+		}
+
+		data, err := os.ReadFile(pdfProcess.OutputPath)
+		assert.NoError(t, err)
+		assert.Equal(t, base64.StdEncoding.EncodeToString(data), pdfProcess.Base64Output)
+	}
+}
+
+func TestProcessFileContext(t *testing.T) {
+	filePath := copyFile(t, "./sample_pdf/process-tree-736885__480.pdf")
+	options := []Option{WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}), WithoutBase64()}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := NewPDFGopher(filePath, options...).ProcessFileContext(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// A hung pdfcpu is killed when the deadline passes
+	binDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(binDir, "pdfcpu"), []byte("#!/bin/sh\nexec sleep 30\n"), 0755))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	ctx, cancel = context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = NewPDFGopher(filePath, options...).ProcessFileContext(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
+
+func TestDecryptToFile(t *testing.T) {
+	filePath := copyFile(t, "./sample_pdf/process-tree-736885__480.pdf")
+	err := exec.Command("pdfcpu", "encrypt", "--upw", "secret", "--opw", "secret", filePath).Run()
+	assert.NoError(t, err)
+
+	output := filepath.Join(t.TempDir(), "unlocked.pdf")
+
+	err = DecryptToFile(filePath, "wrong", output)
+	assert.ErrorIs(t, err, ErrWrongPassword)
+	assert.NoFileExists(t, output)
+
+	err = DecryptToFile(filePath, "secret", output)
+	assert.NoError(t, err)
+
+	// The copy opens without a password, the input stays protected
+	info := readInfo(t, output)
+	assert.False(t, info.Encrypted)
+	assert.Equal(t, 1, info.PageCount)
+	assert.True(t, readInfo(t, filePath, "--upw", "secret").Encrypted)
+
+	// An unprotected file decrypted onto itself is left as it is
+	original, err := os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.NoError(t, DecryptToFile(output, "", output))
+
+	data, err := os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Equal(t, original, data)
+
+	// A protected file is decrypted in place
+	assert.NoError(t, DecryptToFile(filePath, "secret", filePath))
+
+	info = readInfo(t, filePath)
+	assert.False(t, info.Encrypted)
+	assert.Equal(t, 1, info.PageCount)
+
+	entries, err := os.ReadDir(filepath.Dir(filePath))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestProcessFileTempDir(t *testing.T) {
+	tempDir := t.TempDir()
+
+	for _, input := range []string{"./sample_pdf/process-tree-736885__480.pdf", "./sample_image/tree-736885__480.jpg"} {
+		filePath := copyFile(t, input)
+
+		pdfProcess := NewPDFGopher(filePath,
+			WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+			WithTempDir(tempDir),
+			WithoutBase64(),
+		)
+		assert.NoError(t, pdfProcess.ProcessFile())
+		assert.Equal(t, tempDir, filepath.Dir(pdfProcess.OutputPath))
+
+		// Nothing is written next to the input
+		entries, err := os.ReadDir(filepath.Dir(filePath))
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+
+		assert.NoError(t, pdfProcess.Close())
+	}
+
+	entries, err := os.ReadDir(tempDir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestProcessFileGeneratedQR(t *testing.T) {
+	tempDir := t.TempDir()
+
+	pdfProcess := NewPDFGopher(copyFile(t, "./sample_pdf/process-tree-736885__480.pdf"),
+		WithGeneratedQR("https://example.com/doc/7", ""),
+		WithExistingStamps(StampReplace),
+		WithTempDir(tempDir),
+		WithoutBase64(),
+	)
+	assert.NoError(t, pdfProcess.ProcessFile())
+
+	data, err := ExtractQRData(pdfProcess.OutputPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/doc/7", data)
+
+	// Only the output is left, the generated image is removed
+	entries, err := os.ReadDir(tempDir)
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, filepath.Base(pdfProcess.OutputPath), entries[0].Name())
+	}
+}
+
+func TestProcessFileErrors(t *testing.T) {
+	textPath := filepath.Join(t.TempDir(), "notes.txt")
+	assert.NoError(t, os.WriteFile(textPath, []byte("plain text"), 0644))
+
+	err := NewPDFGopher(textPath).ProcessFile()
+	assert.ErrorIs(t, err, ErrUnsupportedFileType)
+	assert.EqualError(t, err, "unsupported file type: .txt")
+	assert.ErrorIs(t, Open(textPath).Err(), ErrUnsupportedFileType)
+
+	protected := NewPDFGopher(copyFile(t, "./sample_pdf/soal_no_3_protected_protected.pdf"),
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+	)
+	err = protected.ProcessFile()
+	assert.ErrorIs(t, err, ErrMissingPassword)
+	assert.EqualError(t, err, "password is empty: soal_no_3_protected_protected.pdf is password protected")
+
+	withoutQRCode := NewPDFGopher(copyFile(t, "./sample_pdf/process-tree-736885__480.pdf"))
+	assert.ErrorIs(t, withoutQRCode.ProcessFile(), ErrMissingQRCode)
+}
+
+func TestProcessPDFInvalidStampPosition(t *testing.T) {
+	const expected = `invalid stamp position: "bottom-right", expected one of tl, tc, tr, l, c, r, bl, bc, br`
+
+	filePath := copyFile(t, "./sample_pdf/process-tree-736885__480.pdf")
+
+	pdfProcess := NewPDFGopher(filePath,
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+		WithStampPosition("bottom-right"),
+	)
+	assert.EqualError(t, pdfProcess.ProcessFile(), expected)
+	assert.Empty(t, pdfProcess.OutputPath)
+
+	textStamp := NewPDFGopher(filePath,
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+		WithTextStamp("CONFIDENTIAL", "bottom-right"),
+	)
+	assert.EqualError(t, textStamp.ProcessFile(), expected)
+
+	err := Open(filePath).
+		Stamp(StampSpec{ImagePath: "./sample_image/qr-generate.png", Position: "bottom-right"}).
+		Save(filepath.Join(t.TempDir(), "stamped.pdf"))
+	assert.EqualError(t, err, expected)
+	assert.EqualError(t, CheckStampBounds(filePath, StampSpec{ImagePath: "./sample_image/qr-generate.png", Position: "bottom-right"}), expected)
+}
+
+func TestProcessPDFWithStamps(t *testing.T) {
+	pdfProcess := NewPDFGopher(multiPagePDF(t, 2),
+		WithStamps(
+			StampSpec{ImagePath: "./sample_image/qr-generate.png", Position: TopLeft, Pages: "odd"},
+			StampSpec{ImagePath: "./sample_image/privyid-favicon.png", Position: BottomRight, Pages: "even"},
+		),
+		WithoutBase64(),
+	)
+	assert.NoError(t, pdfProcess.ProcessFile())
+
+	stampPattern := regexp.MustCompile(`(-?[\d.]+) (-?[\d.]+) cm /GS\d+ gs /Fm\d+ Do`)
+	streams := pageStreams(t, pdfProcess.OutputPath)
+	if assert.Len(t, streams, 2) {
+		// Every page gets the stamp selected for it, at its own position
+		first := stampPattern.FindAllStringSubmatch(streams[0], -1)
+		if assert.Len(t, first, 1) {
+			x, _ := strconv.ParseFloat(first[0][1], 64)
+			y, _ := strconv.ParseFloat(first[0][2], 64)
+			assert.Zero(t, x)
+			assert.Greater(t, y, 400.0)
+		}
+
+		second := stampPattern.FindAllStringSubmatch(streams[1], -1)
+		if assert.Len(t, second, 1) {
+			x, _ := strconv.ParseFloat(second[0][1], 64)
+			y, _ := strconv.ParseFloat(second[0][2], 64)
+			assert.Greater(t, x, 300.0)
+			assert.Zero(t, y)
+		}
+	}
+
+	// Every spec is validated on its own
+	invalid := NewPDFGopher(multiPagePDF(t, 2),
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+		WithStamps(StampSpec{Pages: "odd"}, StampSpec{Position: "middle"}),
+	)
+	assert.EqualError(t, invalid.ProcessFile(), `stamp 2: invalid stamp position: "middle", expected one of tl, tc, tr, l, c, r, bl, bc, br`)
+
+	noPages := NewPDFGopher(multiPagePDF(t, 1),
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+		WithStamps(StampSpec{Pages: "odd"}, StampSpec{Pages: "even"}),
+	)
+	assert.EqualError(t, noPages.ProcessFile(), "stamp 2: no pages selected by even")
+
+	// A spec without pages stamps the StampPages of the processor
+	defaultPages := NewPDFGopher(multiPagePDF(t, 2),
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png", StampPages: "2"}),
+		WithStamps(StampSpec{Position: TopLeft}),
+		WithoutBase64(),
+	)
+	assert.NoError(t, defaultPages.ProcessFile())
+
+	streams = pageStreams(t, defaultPages.OutputPath)
+	if assert.Len(t, streams, 2) {
+		assert.Empty(t, stampPattern.FindAllString(streams[0], -1))
+		assert.Len(t, stampPattern.FindAllString(streams[1], -1), 1)
+	}
+}
+
+func TestBase64To(t *testing.T) {
+	pdfProcess := NewPDFGopher(copyFile(t, "./sample_pdf/process-tree-736885__480.pdf"),
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+	)
+
+	var buf bytes.Buffer
+	assert.EqualError(t, pdfProcess.Base64To(&buf), "file has not been processed")
+
+	assert.NoError(t, pdfProcess.ProcessFile())
+	assert.NoError(t, pdfProcess.Base64To(&buf))
+	assert.Equal(t, pdfProcess.Base64Output, buf.String())
+
+	// The encoding is streamed without Base64Output
+	streamed := NewPDFGopher(copyFile(t, "./sample_pdf/process-tree-736885__480.pdf"),
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+		WithoutBase64(),
+	)
+	assert.NoError(t, streamed.ProcessFile())
+
+	buf.Reset()
+	assert.NoError(t, streamed.Base64To(&buf))
+	assert.Empty(t, streamed.Base64Output)
+
+	decoded, err := base64.StdEncoding.DecodeString(buf.String())
+	assert.NoError(t, err)
+
+	output, err := os.ReadFile(streamed.OutputPath)
+	assert.NoError(t, err)
+	assert.Equal(t, output, decoded)
+}
+
+func TestProcessFileOutputPath(t *testing.T) {
+	input := copyFile(t, "./sample_pdf/process-tree-736885__480.pdf")
+	original, err := os.ReadFile(input)
+	assert.NoError(t, err)
+
+	output := filepath.Join(t.TempDir(), "stamped.pdf")
+	options := []Option{
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+		WithOutputPath(output),
+		WithoutBase64(),
+	}
+
+	pdfProcess := NewPDFGopher(input, options...)
+	assert.NoError(t, pdfProcess.ProcessFile())
+	assert.Equal(t, output, pdfProcess.OutputPath)
+
+	// The input is preserved and the output outlives the processor
+	current, err := os.ReadFile(input)
+	assert.NoError(t, err)
+	assert.Equal(t, original, current)
+	assert.NoError(t, pdfProcess.Close())
+	assert.FileExists(t, output)
+
+	// An existing output is only replaced with WithOverwrite
+	err = NewPDFGopher(input, options...).ProcessFile()
+	assert.ErrorIs(t, err, os.ErrExist)
+	assert.NoError(t, NewPDFGopher(input, append(options, WithOverwrite(true))...).ProcessFile())
+
+	// A failed run leaves the existing output alone
+	failed := NewPDFGopher(input, append(options, WithOverwrite(true), WithOptionFilePDF(OptionFilePDF{QRCodePath: "missing.png"}))...)
+	assert.Error(t, failed.ProcessFile())
+	assert.FileExists(t, output)
+
+	// Converted images are written to the output path as well
+	imageOutput := filepath.Join(t.TempDir(), "tree.pdf")
+	image := NewPDFGopher(copyFile(t, "./sample_image/tree-736885__480.jpg"),
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+		WithOutputPath(imageOutput),
+		WithoutBase64(),
+	)
+	assert.NoError(t, image.ProcessFile())
+	assert.Equal(t, imageOutput, image.OutputPath)
+	assert.NoError(t, image.Close())
+	assert.Equal(t, 1, readInfo(t, imageOutput).PageCount)
+}
@@ -0,0 +1,47 @@
+package pdfgopher
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// WithPDFStamp returns an Option function that stamps the given page of the PDF file at pdfPath,
+// the first page when page is zero, instead of the QR code, e.g. a vector logo or letterhead.
+// The position, scale, rotation, offset and opacity of OptionFilePDF apply as for the QR code.
+func WithPDFStamp(pdfPath string, page int) Option {
+	return func(p *PDFProcessor) {
+		p.pdfStampPath = pdfPath
+		p.pdfStampPage = page
+	}
+}
+
+// openPDFStampSource returns the page of the PDF file at pdfPath as a stamp source,
+// the first page when page is zero.
+func openPDFStampSource(ctx context.Context, pdfPath string, page int) (stampSource, error) {
+	if page < 0 {
+		return stampSource{}, fmt.Errorf("invalid stamp page: %d", page)
+	}
+	if page == 0 {
+		page = 1
+	}
+
+	_, err := os.Stat(pdfPath)
+	if os.IsNotExist(err) {
+		return stampSource{}, fmt.Errorf("file not found: %s", pdfPath)
+	} else if err != nil {
+		return stampSource{}, err
+	}
+
+	sizes, err := pageSizes(ctx, pdfPath)
+	if err != nil {
+		return stampSource{}, err
+	}
+
+	if page > len(sizes) {
+		return stampSource{}, fmt.Errorf("stamp page %d out of range: %s has %d pages", page, pdfPath, len(sizes))
+	}
+
+	size := sizes[page-1]
+	return stampSource{Mode: "pdf", Operand: fmt.Sprintf("%s:%d", pdfPath, page), Width: size.Width, Height: size.Height}, nil
+}
@@ -0,0 +1,51 @@
+package pdfgopher_test
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+
+	. "github.com/RamdhaniMichan/PDFGopher"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessPDFPDFStamp(t *testing.T) {
+	logo := multiPagePDF(t, 2)
+
+	pdfProcess := NewPDFGopher(multiPagePDF(t, 1),
+		WithOptionFilePDF(OptionFilePDF{StampPosition: TopLeft}),
+		WithPDFStamp(logo, 2),
+		WithoutBase64(),
+	)
+	assert.NoError(t, pdfProcess.ProcessFile())
+
+	// The page is embedded as vector content instead of an image
+	assert.Contains(t, pageStreams(t, pdfProcess.OutputPath)[0], "(Page 2)Tj")
+	assert.Zero(t, countImages(t, pdfProcess.OutputPath))
+
+	missingPage := NewPDFGopher(multiPagePDF(t, 1), WithPDFStamp(logo, 3), WithoutBase64())
+	assert.EqualError(t, missingPage.ProcessFile(), "stamp page 3 out of range: "+logo+" has 2 pages")
+}
+
+func TestStampPDFPage(t *testing.T) {
+	logo := multiPagePDF(t, 1)
+
+	// An A4 page, 595.28 points wide, is fitted into a 100 points wide box
+	output := filepath.Join(t.TempDir(), "stamped.pdf")
+	err := Open(multiPagePDF(t, 1)).Stamp(StampSpec{PDFPath: logo, MaxWidth: 100}).Save(output)
+	assert.NoError(t, err)
+
+	match := regexp.MustCompile(`([\d.]+) 0\.00000 0\.00000 [\d.]+ 0\.00000 0\.00000 cm`).FindStringSubmatch(pageStreams(t, output)[0])
+	if assert.NotNil(t, match) {
+		scale, _ := strconv.ParseFloat(match[1], 64)
+		assert.InDelta(t, 100/595.28, scale, 0.001)
+	}
+
+	assert.NoError(t, CheckStampBounds(output, StampSpec{PDFPath: logo, MaxWidth: 100}))
+
+	err = CheckStampBounds(output, StampSpec{PDFPath: logo, MaxWidth: 100, OffsetX: 50})
+	var boundsErr *StampBoundsError
+	assert.ErrorAs(t, err, &boundsErr)
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package pdfgopher
+
+import "os/exec"
+
+// killProcessGroupOnCancel keeps the default cancellation of cmd, process groups are unix only.
+func killProcessGroupOnCancel(cmd *exec.Cmd) {}
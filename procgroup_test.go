@@ -0,0 +1,64 @@
+//go:build linux
+
+package pdfgopher_test
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/RamdhaniMichan/PDFGopher"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessFileConversionTimeout(t *testing.T) {
+	binDir := t.TempDir()
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	// A fake soffice that hangs in a child process, like a stuck LibreOffice worker
+	pidFile := filepath.Join(t.TempDir(), "worker.pid")
+	t.Setenv("WORKER_PID_FILE", pidFile)
+
+	script := `#!/bin/sh
+sleep 300 &
+echo $! > "$WORKER_PID_FILE"
+wait
+`
+	assert.NoError(t, os.WriteFile(filepath.Join(binDir, "soffice"), []byte(script), 0755))
+
+	documentPath := filepath.Join(t.TempDir(), "corrupt.docx")
+	assert.NoError(t, os.WriteFile(documentPath, []byte("document"), 0644))
+
+	pdfProcess := NewPDFGopher(documentPath, WithConversionTimeout(500*time.Millisecond))
+
+	start := time.Now()
+	err := pdfProcess.ProcessFile()
+
+	assert.ErrorContains(t, err, "soffice conversion timed out after 500ms")
+	assert.Less(t, time.Since(start), 10*time.Second)
+
+	data, err := os.ReadFile(pidFile)
+	if assert.NoError(t, err) {
+		pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		assert.NoError(t, err)
+
+		// The worker is killed with the process group, it may linger as a zombie until reaped
+		assert.Eventually(t, func() bool { return !processRunning(pid) }, 5*time.Second, 50*time.Millisecond)
+	}
+}
+
+// processRunning reports whether the process with the given ID exists and isn't a zombie.
+func processRunning(pid int) bool {
+	stat, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return false
+	}
+
+	// The state follows the parenthesized command name
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+	return len(fields) > 0 && fields[0] != "Z"
+}
@@ -0,0 +1,76 @@
+package pdfgopher
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+// NewPDFGopherFromReader constructor to retrieve struct PDFProcessor for input read from r,
+// such as an HTTP upload. The input is buffered to a temporary file, which Close removes.
+// There is no file name to infer the type from, so fileType must be given.
+func NewPDFGopherFromReader(r io.Reader, fileType FileType, options ...Option) (*PDFProcessor, error) {
+	reader := bufio.NewReader(r)
+
+	// The extension selects the pipeline, image and document converters also rely on it
+	header, _ := reader.Peek(512)
+	extension, err := inputExtension(fileType, header)
+	if err != nil {
+		return nil, err
+	}
+
+	processor := NewPDFGopher("", options...)
+
+	file, err := os.CreateTemp(processor.tempDir, "pdfgopher-input-*"+extension)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = io.Copy(file, reader)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return nil, err
+	}
+
+	processor.FilePath = file.Name()
+	processor.trackTempFile(file.Name())
+
+	return processor, nil
+}
+
+// inputExtension returns the file extension for input of fileType starting with header.
+func inputExtension(fileType FileType, header []byte) (string, error) {
+	switch fileType {
+	case PDF:
+		return ".pdf", nil
+	case Image:
+		switch contentType := http.DetectContentType(header); contentType {
+		case "image/png":
+			return ".png", nil
+		case "image/jpeg":
+			return ".jpg", nil
+		case "image/webp":
+			return ".webp", nil
+		default:
+			// TIFF isn't detected by http.DetectContentType
+			if _, extension := sniffFileType(header); extension == ".tif" {
+				return extension, nil
+			}
+			return "", fmt.Errorf("unsupported image content type: %s", contentType)
+		}
+	case Document:
+		// DOCX files are ZIP archives, DOC files are OLE compound files
+		if bytes.HasPrefix(header, []byte("PK\x03\x04")) {
+			return ".docx", nil
+		}
+		return ".doc", nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedFileType, fileType)
+	}
+}
@@ -0,0 +1,50 @@
+package pdfgopher_test
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/RamdhaniMichan/PDFGopher"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPDFGopherFromReader(t *testing.T) {
+	inputs := map[FileType]string{
+		PDF:   "./sample_pdf/process-tree-736885__480.pdf",
+		Image: "./sample_image/tree-736885__480.jpg",
+	}
+
+	for fileType, input := range inputs {
+		data, err := os.ReadFile(input)
+		assert.NoError(t, err)
+
+		pdfProcess, err := NewPDFGopherFromReader(bytes.NewReader(data), fileType,
+			WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+		)
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		assert.NoError(t, pdfProcess.ProcessFile())
+		assert.NotEmpty(t, pdfProcess.Base64Output)
+		assert.FileExists(t, pdfProcess.FilePath)
+		assert.FileExists(t, pdfProcess.OutputPath)
+
+		// Close removes the buffered input along with the output
+		outputPath := pdfProcess.OutputPath
+		assert.NoError(t, pdfProcess.Close())
+		assert.NoFileExists(t, pdfProcess.FilePath)
+		assert.NoFileExists(t, outputPath)
+	}
+}
+
+func TestNewPDFGopherFromReaderInvalidInput(t *testing.T) {
+	_, err := NewPDFGopherFromReader(strings.NewReader("plain text"), Image)
+	assert.EqualError(t, err, "unsupported image content type: text/plain; charset=utf-8")
+
+	_, err = NewPDFGopherFromReader(strings.NewReader("plain text"), FileType("text"))
+	assert.EqualError(t, err, `unsupported file type: "text"`)
+}
@@ -0,0 +1,202 @@
+package pdfgopher
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultRenderDPI is the resolution used when rendering pages without an explicit DPI.
+const defaultRenderDPI = 72
+
+// InkCoverage returns the fraction of non-white pixels of every page of the PDF file,
+// rendered at 72 DPI.
+func InkCoverage(filePath string) ([]float64, error) {
+	return InkCoverageWithDPI(filePath, defaultRenderDPI)
+}
+
+// InkCoverageWithDPI returns the fraction of non-white pixels of every page of the PDF file,
+// rendered at the given DPI. Higher DPI values are more accurate but slower.
+func InkCoverageWithDPI(filePath string, dpi int) ([]float64, error) {
+	if dpi <= 0 {
+		return nil, fmt.Errorf("invalid DPI: %d", dpi)
+	}
+
+	dir, err := os.MkdirTemp("", "render-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	pages, err := renderPages(filePath, dpi, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	coverage := make([]float64, len(pages))
+	for i, page := range pages {
+		img, err := decodeImageFile(page)
+		if err != nil {
+			return nil, err
+		}
+
+		coverage[i] = inkFraction(img)
+	}
+
+	return coverage, nil
+}
+
+// renderPages renders every page of the PDF file to a PNG file in dir using pdftoppm.
+// The returned paths are ordered by page number.
+func renderPages(filePath string, dpi int, dir string) ([]string, error) {
+	return PagesToImages(filePath, dir, WithRenderDPI(dpi))
+}
+
+// RenderOption is a function type used for applying options to page rendering.
+type RenderOption func(*renderConfig)
+
+// renderConfig holds the options applied when rendering pages.
+type renderConfig struct {
+	dpi         int
+	concurrency int
+}
+
+// WithRenderDPI returns a RenderOption function that sets the rendering resolution, 72 DPI by default.
+func WithRenderDPI(dpi int) RenderOption {
+	return func(c *renderConfig) {
+		c.dpi = dpi
+	}
+}
+
+// WithRenderConcurrency returns a RenderOption function that caps the number of pages rendered
+// at the same time, the number of CPUs by default.
+func WithRenderConcurrency(workers int) RenderOption {
+	return func(c *renderConfig) {
+		c.concurrency = workers
+	}
+}
+
+// pageRasterizer renders a single page to a PNG file, it is replaced in tests to observe rendering.
+var pageRasterizer = rasterizePage
+
+// PagesToImages renders every page of the PDF file to a PNG file in outputDir using a pool of
+// pdftoppm workers. The files are named page-N.png, with N zero-padded to the digits of the page
+// count, and the returned paths are ordered by page number.
+func PagesToImages(filePath string, outputDir string, options ...RenderOption) ([]string, error) {
+	config := renderConfig{dpi: defaultRenderDPI, concurrency: runtime.NumCPU()}
+	for _, opt := range options {
+		opt(&config)
+	}
+
+	if config.dpi <= 0 {
+		return nil, fmt.Errorf("invalid DPI: %d", config.dpi)
+	}
+
+	if config.concurrency <= 0 {
+		return nil, fmt.Errorf("invalid concurrency: %d", config.concurrency)
+	}
+
+	count, err := pageCount(context.Background(), filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.MkdirAll(outputDir, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	digits := len(strconv.Itoa(count))
+	pages := make([]string, count)
+	for i := range pages {
+		pages[i] = filepath.Join(outputDir, fmt.Sprintf("page-%0*d.png", digits, i+1))
+	}
+
+	jobs := make(chan int)
+	errs := make([]error, count)
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < config.concurrency && worker < count; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range jobs {
+				errs[i] = pageRasterizer(filePath, i+1, config.dpi, pages[i])
+			}
+		}()
+	}
+
+	for i := range pages {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return pages, nil
+}
+
+// rasterizePage renders a single page of the PDF file to the PNG file output using pdftoppm.
+func rasterizePage(filePath string, page int, dpi int, output string) error {
+	pageNumber := strconv.Itoa(page)
+
+	// Execute the command
+	cmd := exec.Command(executable("pdftoppm"), "-png", "-singlefile", "-r", strconv.Itoa(dpi), "-f", pageNumber, "-l", pageNumber, filePath, strings.TrimSuffix(output, ".png"))
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("error executing pdftoppm command: %s", err.Error())
+	}
+
+	return nil
+}
+
+// decodeImageFile decodes the image file at filePath.
+func decodeImageFile(filePath string) (image.Image, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return img, nil
+}
+
+// inkFraction returns the fraction of pixels of img that are not (nearly) white.
+func inkFraction(img image.Image) float64 {
+	bounds := img.Bounds()
+	total := bounds.Dx() * bounds.Dy()
+	if total == 0 {
+		return 0
+	}
+
+	inked := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			// Treat anything darker than 98% white on any channel as ink
+			if r < 0xfae1 || g < 0xfae1 || b < 0xfae1 {
+				inked++
+			}
+		}
+	}
+
+	return float64(inked) / float64(total)
+}
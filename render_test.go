@@ -0,0 +1,96 @@
+package pdfgopher_test
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	. "github.com/RamdhaniMichan/PDFGopher"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInkCoverage(t *testing.T) {
+	if _, err := exec.LookPath("pdftoppm"); err != nil {
+		t.Skip("pdftoppm is not installed")
+	}
+
+	filePath := filepath.Join(t.TempDir(), "coverage.pdf")
+
+	// A nearly blank page followed by a page filled with a dark rectangle
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.AddPage()
+	pdf.Cell(0, 5, ".")
+	pdf.AddPage()
+	pdf.SetFillColor(0, 0, 0)
+	pdf.Rect(10, 10, 190, 270, "F")
+	assert.NoError(t, pdf.OutputFileAndClose(filePath))
+
+	coverage, err := InkCoverage(filePath)
+
+	assert.NoError(t, err)
+	if assert.Len(t, coverage, 2) {
+		assert.Less(t, coverage[0], 0.01)
+		assert.Greater(t, coverage[1], coverage[0])
+	}
+}
+
+func TestPagesToImages(t *testing.T) {
+	if _, err := exec.LookPath("pdftoppm"); err != nil {
+		t.Skip("pdftoppm is not installed")
+	}
+
+	filePath := multiPagePDF(t, 3)
+
+	pages, err := PagesToImages(filePath, t.TempDir(), WithRenderConcurrency(2))
+
+	assert.NoError(t, err)
+	if assert.Len(t, pages, 3) {
+		for _, page := range pages {
+			assert.FileExists(t, page)
+		}
+	}
+}
+
+func BenchmarkPagesToImages(b *testing.B) {
+	if _, err := exec.LookPath("pdftoppm"); err != nil {
+		b.Skip("pdftoppm is not installed")
+	}
+
+	filePath := multiPagePDF(b, 16)
+
+	for _, workers := range []int{1, runtime.NumCPU()} {
+		b.Run(fmt.Sprintf("workers-%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, err := PagesToImages(filePath, b.TempDir(), WithRenderDPI(150), WithRenderConcurrency(workers))
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// multiPagePDF creates a PDF file with the given number of text pages.
+func multiPagePDF(tb testing.TB, count int) string {
+	tb.Helper()
+
+	filePath := filepath.Join(tb.TempDir(), "pages.pdf")
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Helvetica", "", 12)
+	for i := 1; i <= count; i++ {
+		pdf.AddPage()
+		pdf.Cell(0, 10, fmt.Sprintf("Page %d", i))
+	}
+
+	if err := pdf.OutputFileAndClose(filePath); err != nil {
+		tb.Fatal(err)
+	}
+
+	return filePath
+}
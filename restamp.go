@@ -0,0 +1,154 @@
+package pdfgopher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/makiuchi-d/gozxing"
+	gozxingqr "github.com/makiuchi-d/gozxing/qrcode"
+	"golang.org/x/image/draw"
+)
+
+// ErrNoQRCode is returned when no decodable QR code is found in a PDF file.
+var ErrNoQRCode = errors.New("no QR code found")
+
+// RestampQR decodes the QR code stamped on the input PDF file, removes the existing stamps
+// and stamps an equivalent QR code, writing the result to output. The options place and size it
+// like ProcessFile, pass the options the input was processed with to keep the stamp where it was:
+// StampPages, StampPosition, StampWidthRatio, StampScale, the offsets and the icon of WithGeneratedQR.
+// It is meant for documents whose content was edited after stamping. The input is left unchanged
+// unless output is the same file, which is then only replaced once restamping succeeded.
+// It returns ErrNoQRCode when the input has no decodable QR code.
+func RestampQR(input string, output string, options ...Option) error {
+	data, err := ExtractQRData(input)
+	if err != nil {
+		return err
+	}
+
+	p := NewPDFGopher(input, options...)
+
+	dir, err := os.MkdirTemp("", "restamp-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	qrCode := filepath.Join(dir, "qr.png")
+	if p.qrIconPath == "" {
+		_, err = GenerateQRCode(data, qrCode)
+	} else {
+		_, err = GenerateQRCodeWithIcon(data, p.qrIconPath, qrCode)
+	}
+	if err != nil {
+		return err
+	}
+
+	spec := p.withStampDefaults(StampSpec{ImagePath: qrCode})
+	err = validateStampSpec(spec)
+	if err != nil {
+		return err
+	}
+
+	// Restamp a copy next to output, input and output may be the same file
+	return stageFile(output, func(tempPath string) error {
+		err := copyFile(input, tempPath)
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		pages, err := pageCount(ctx, tempPath)
+		if err != nil {
+			return err
+		}
+
+		selected, err := parsePageSelection(spec.Pages, pages)
+		if err != nil {
+			return err
+		}
+		if len(selected) == 0 {
+			return fmt.Errorf("no pages selected by %s", spec.Pages)
+		}
+
+		err = removeStamps(ctx, tempPath)
+		if err != nil {
+			return err
+		}
+
+		err = addImageStamp(ctx, tempPath, spec, selected)
+		if err != nil {
+			return err
+		}
+
+		return verifyPageCount(ctx, tempPath, pages)
+	})
+}
+
+// ExtractQRData returns the payload of the first QR code found in the images of the PDF file.
+// It returns ErrNoQRCode when none of the images is a decodable QR code.
+func ExtractQRData(filePath string) (string, error) {
+	dir, err := os.MkdirTemp("", "qr-images-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	_, err = runPDFCPU(context.Background(), "images", "extract", filePath, dir)
+	if err != nil {
+		return "", err
+	}
+
+	images, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(images)
+
+	for _, imagePath := range images {
+		img, err := decodeImageFile(imagePath)
+		if err != nil {
+			// Skip images in formats that can't be decoded
+			continue
+		}
+
+		data, err := decodeQRCode(img)
+		if err == nil {
+			return data, nil
+		}
+	}
+
+	return "", ErrNoQRCode
+}
+
+// decodeQRCode decodes the QR code in img. A quiet zone is added first,
+// since stamped QR codes are generated without one.
+func decodeQRCode(img image.Image) (string, error) {
+	bounds := img.Bounds()
+	margin := bounds.Dx() / 10
+	if margin < 4 {
+		margin = 4
+	}
+
+	padded := image.NewRGBA(image.Rect(0, 0, bounds.Dx()+2*margin, bounds.Dy()+2*margin))
+	draw.Draw(padded, padded.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(padded, bounds.Sub(bounds.Min).Add(image.Pt(margin, margin)), img, bounds.Min, draw.Over)
+
+	bitmap, err := gozxing.NewBinaryBitmapFromImage(padded)
+	if err != nil {
+		return "", err
+	}
+
+	hints := map[gozxing.DecodeHintType]interface{}{gozxing.DecodeHintType_TRY_HARDER: true}
+	result, err := gozxingqr.NewQRCodeReader().Decode(bitmap, hints)
+	if err != nil {
+		return "", err
+	}
+
+	return result.GetText(), nil
+}
@@ -0,0 +1,111 @@
+package pdfgopher_test
+
+import (
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	. "github.com/RamdhaniMichan/PDFGopher"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestampQR(t *testing.T) {
+	filePath := copyFile(t, "./sample_pdf/process-tree-736885__480.pdf")
+
+	pdfProcess := NewPDFGopher(filePath,
+		WithGeneratedQR("https://example.com/doc/42", "./sample_image/privyid-favicon.png"),
+		WithExistingStamps(StampReplace),
+		WithoutBase64(),
+	)
+	assert.NoError(t, pdfProcess.ProcessFile())
+
+	// Edit the content after stamping by inserting a blank page
+	err := exec.Command("pdfcpu", "pages", "insert", "--pages", "1", pdfProcess.OutputPath).Run()
+	assert.NoError(t, err)
+
+	output := filepath.Join(t.TempDir(), "restamped.pdf")
+	err = RestampQR(pdfProcess.OutputPath, output)
+	assert.NoError(t, err)
+
+	data, err := ExtractQRData(output)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/doc/42", data)
+
+	// The previous stamp is replaced by a single stamp on every page
+	assert.Equal(t, 2, readInfo(t, output).PageCount)
+	assert.Equal(t, 2, countImages(t, output))
+}
+
+func TestRestampQRNoQRCode(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "blank.pdf")
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	assert.NoError(t, pdf.OutputFileAndClose(filePath))
+
+	err := RestampQR(filePath, filepath.Join(t.TempDir(), "restamped.pdf"))
+	assert.ErrorIs(t, err, ErrNoQRCode)
+}
+
+func TestRestampQRSamePath(t *testing.T) {
+	filePath := copyFile(t, "./sample_pdf/process-tree-736885__480.pdf")
+
+	pdfProcess := NewPDFGopher(filePath,
+		WithGeneratedQR("https://example.com/doc/42", "./sample_image/privyid-favicon.png"),
+		WithExistingStamps(StampReplace),
+		WithoutBase64(),
+	)
+	assert.NoError(t, pdfProcess.ProcessFile())
+
+	err := RestampQR(pdfProcess.OutputPath, pdfProcess.OutputPath)
+	assert.NoError(t, err)
+
+	data, err := ExtractQRData(pdfProcess.OutputPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/doc/42", data)
+	assert.Equal(t, 1, readInfo(t, pdfProcess.OutputPath).PageCount)
+
+	// No temporary file is left next to the output
+	matches, err := filepath.Glob(filepath.Join(filepath.Dir(pdfProcess.OutputPath), "restamp-*"))
+	assert.NoError(t, err)
+	assert.Empty(t, matches)
+}
+
+func TestRestampQRKeepsPlacement(t *testing.T) {
+	options := []Option{
+		WithOptionFilePDF(OptionFilePDF{StampPosition: TopLeft, StampWidthRatio: 0.25, StampOffsetX: 20, StampOffsetY: -30}),
+		WithGeneratedQR("https://example.com/doc/42", "./sample_image/privyid-favicon.png"),
+		WithoutBase64(),
+	}
+
+	pdfProcess := NewPDFGopher(multiPagePDF(t, 2), options...)
+	assert.NoError(t, pdfProcess.ProcessFile())
+
+	stampPattern := regexp.MustCompile(`([-\d.]+ [-\d.]+ [-\d.]+ [-\d.]+ [-\d.]+ [-\d.]+) cm /GS\d+ gs /Fm\d+ Do`)
+	placements := func() []string {
+		var matrices []string
+		for _, page := range pageStreams(t, pdfProcess.OutputPath) {
+			matches := stampPattern.FindAllStringSubmatch(page, -1)
+			if assert.Len(t, matches, 1) {
+				matrices = append(matrices, matches[0][1])
+			}
+		}
+		return matrices
+	}
+
+	before := placements()
+	assert.Len(t, before, 2)
+
+	err := RestampQR(pdfProcess.OutputPath, pdfProcess.OutputPath, options...)
+	assert.NoError(t, err)
+
+	// The stamp keeps its corner, offset and size
+	assert.Equal(t, before, placements())
+
+	data, err := ExtractQRData(pdfProcess.OutputPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/doc/42", data)
+}
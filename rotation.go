@@ -0,0 +1,138 @@
+package pdfgopher
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// WithRotatePages returns an Option function that rotates the selected pages of a PDF file clockwise
+// by angle degrees, a multiple of 90, before the QR code is stamped, so sideways scans are stamped
+// in the corner as displayed. pages takes the page selectors of StampPages, every page when empty.
+func WithRotatePages(angle int, pages string) Option {
+	return func(p *PDFProcessor) {
+		p.rotateAngle = angle
+		p.rotatePages = pages
+	}
+}
+
+// validateRotation checks the angle and the syntax of the page selection of WithRotatePages.
+func validateRotation(angle int, pages string) error {
+	if angle%90 != 0 {
+		return fmt.Errorf("invalid rotation angle: %d, expected a multiple of 90", angle)
+	}
+
+	if pages == "" {
+		return nil
+	}
+
+	_, err := parsePageSelection(pages, 0)
+	return err
+}
+
+// rotatePages rotates the selected pages of the PDF file clockwise by angle degrees. The rotation
+// is baked into the page content, pdfcpu places stamps on rotated pages as if they weren't rotated.
+func rotatePages(ctx context.Context, filePath string, angle int, pages []int) error {
+	angle = (angle%360 + 360) % 360
+	if angle == 0 {
+		return nil
+	}
+
+	_, err := runPDFCPU(ctx, "rotate", "--pages", joinPages(pages), "--", filePath, strconv.Itoa(angle))
+	if err != nil {
+		return err
+	}
+
+	return bakeRotation(ctx, filePath, filePath)
+}
+
+// BakeRotation writes input to output with the /Rotate entry of every page applied to its content,
+// so viewers and text extraction that ignore /Rotate see the pages the way they are displayed.
+// Pages keep their displayed size and orientation. input and output may be the same file.
+func BakeRotation(input string, output string) error {
+	return bakeRotation(context.Background(), input, output)
+}
+
+// bakeRotation bakes the page rotation of input into output like BakeRotation, running pdfcpu with ctx.
+func bakeRotation(ctx context.Context, input string, output string) error {
+	if !sameFile(input, output) {
+		err := copyFile(input, output)
+		if err != nil {
+			return err
+		}
+	}
+
+	info, err := readPDFInfo(ctx, output, "1-")
+	if err != nil {
+		return err
+	}
+
+	// pdfcpu resize bakes the rotation, resizing to the displayed size keeps the scale
+	resizes := make(map[string][]int)
+	// pdfcpu resize changes inherited media boxes for every page sharing them, so each page
+	// gets its own media box first
+	mediaBoxes := make(map[string][]int)
+	for page, boundaries := range info.PageBoundaries {
+		number, err := strconv.Atoi(page)
+		if err != nil {
+			return fmt.Errorf("invalid page number in pdfcpu info: %s", page)
+		}
+
+		media := boundaries.MediaBox.Rect
+		description := fmt.Sprintf("media:[%g %g %g %g]", media.LL.X, media.LL.Y, media.UR.X, media.UR.Y)
+		mediaBoxes[description] = append(mediaBoxes[description], number)
+
+		if boundaries.Rotation%360 == 0 {
+			continue
+		}
+
+		box := boundaries.MediaBox
+		if boundaries.CropBox != nil {
+			box = *boundaries.CropBox
+		}
+
+		width, height := box.Rect.UR.X-box.Rect.LL.X, box.Rect.UR.Y-box.Rect.LL.Y
+		if boundaries.Rotation%180 != 0 {
+			width, height = height, width
+		}
+
+		description = fmt.Sprintf("dim:%g %g", width, height)
+		resizes[description] = append(resizes[description], number)
+	}
+
+	if len(resizes) == 0 {
+		return nil
+	}
+
+	err = runPageGroups(ctx, output, []string{"boxes", "add"}, mediaBoxes)
+	if err != nil {
+		return err
+	}
+
+	return runPageGroups(ctx, output, []string{"resize"}, resizes)
+}
+
+// runPageGroups runs the pdfcpu command on the PDF file once for every description,
+// for the pages grouped under it.
+func runPageGroups(ctx context.Context, filePath string, command []string, groups map[string][]int) error {
+	descriptions := make([]string, 0, len(groups))
+	for description := range groups {
+		descriptions = append(descriptions, description)
+	}
+	sort.Strings(descriptions)
+
+	for _, description := range descriptions {
+		pages := groups[description]
+		sort.Ints(pages)
+
+		args := append(append([]string{}, command...), "--pages", joinPages(pages), "--", description, filePath)
+
+		_, err := runPDFCPU(ctx, args...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package pdfgopher_test
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+
+	. "github.com/RamdhaniMichan/PDFGopher"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBakeRotation(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "rotated.pdf")
+	output := filepath.Join(dir, "baked.pdf")
+
+	pdf := gofpdf.New("P", "pt", "A4", "")
+	pdf.SetFont("Helvetica", "", 24)
+	pdf.AddPage()
+	pdf.Text(50, 100, "Upright text")
+	pdf.AddPage()
+	pdf.Text(50, 100, "Second page")
+	assert.NoError(t, pdf.OutputFileAndClose(input))
+
+	assert.NoError(t, exec.Command("pdfcpu", "rotate", "--pages", "1", input, "90").Run())
+
+	assert.NoError(t, BakeRotation(input, output))
+
+	info := readInfo(t, output, "--pages", "1-")
+	if assert.Len(t, info.PageBoundaries, 2) {
+		// The rotated page keeps its landscape display size without a /Rotate entry
+		first := info.PageBoundaries["1"]
+		assert.Equal(t, 0, first.Rotation)
+		assert.InDelta(t, 841.89, first.MediaBox.Rect.UR.X, 0.01)
+		assert.InDelta(t, 595.28, first.MediaBox.Rect.UR.Y, 0.01)
+
+		second := info.PageBoundaries["2"]
+		assert.Equal(t, 0, second.Rotation)
+		assert.InDelta(t, 595.28, second.MediaBox.Rect.UR.X, 0.01)
+	}
+
+	pages := pageStreams(t, output)
+	if assert.Len(t, pages, 2) {
+		assert.Contains(t, pages[0], "0.00000 -1.00000 1.00000 0.00000 0.00000 595.28000 cm")
+		assert.Contains(t, pages[0], "(Upright text) Tj")
+		assert.NotContains(t, pages[1], " cm")
+	}
+
+	if _, err := exec.LookPath("pdftotext"); err == nil {
+		text, err := ExtractText(output, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, "Upright text", text)
+	}
+
+	// Differently spelled paths of the same file are baked in place
+	assert.NoError(t, exec.Command("pdfcpu", "rotate", "--pages", "2", output, "90").Run())
+	assert.NoError(t, BakeRotation(output, dir+string(filepath.Separator)+"."+string(filepath.Separator)+"baked.pdf"))
+
+	info = readInfo(t, output, "--pages", "1-")
+	if assert.Len(t, info.PageBoundaries, 2) {
+		second := info.PageBoundaries["2"]
+		assert.Equal(t, 0, second.Rotation)
+		assert.InDelta(t, 841.89, second.MediaBox.Rect.UR.X, 0.01)
+	}
+}
+
+func TestProcessPDFRotatePages(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "sideways.pdf")
+
+	pdf := gofpdf.New("P", "pt", "A4", "")
+	pdf.AddPage()
+	pdf.AddPage()
+	assert.NoError(t, pdf.OutputFileAndClose(input))
+
+	pdfProcess := NewPDFGopher(input,
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+		WithRotatePages(90, "1"),
+		WithoutBase64(),
+	)
+	assert.NoError(t, pdfProcess.ProcessFile())
+
+	info := readInfo(t, pdfProcess.OutputPath, "--pages", "1-")
+	if assert.Len(t, info.PageBoundaries, 2) {
+		first := info.PageBoundaries["1"]
+		assert.Equal(t, 0, first.Rotation)
+		assert.InDelta(t, 841.89, first.MediaBox.Rect.UR.X, 0.01)
+
+		second := info.PageBoundaries["2"]
+		assert.InDelta(t, 595.28, second.MediaBox.Rect.UR.X, 0.01)
+	}
+
+	// The stamp lands in the bottom right corner of the landscape page
+	pages := pageStreams(t, pdfProcess.OutputPath)
+	if assert.Len(t, pages, 2) {
+		match := regexp.MustCompile(`(-?[\d.]+) (-?[\d.]+) cm /GS\d+ gs /Fm\d+ Do`).FindStringSubmatch(pages[0])
+		if assert.NotNil(t, match) {
+			x, _ := strconv.ParseFloat(match[1], 64)
+			assert.Greater(t, x, 595.28)
+			assert.Less(t, x, 841.89)
+		}
+	}
+
+	err := NewPDFGopher(input, WithRotatePages(45, ""), WithoutBase64()).ProcessFile()
+	assert.EqualError(t, err, "invalid rotation angle: 45, expected a multiple of 90")
+}
+
+func TestProcessPDFRotatePagesDryRun(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "sideways.pdf")
+
+	pdf := gofpdf.New("P", "pt", "A4", "")
+	pdf.AddPage()
+	pdf.AddPage()
+	assert.NoError(t, pdf.OutputFileAndClose(input))
+
+	// The second page is already displayed sideways
+	assert.NoError(t, exec.Command("pdfcpu", "rotate", "--pages", "2", input, "90").Run())
+	original, err := os.ReadFile(input)
+	assert.NoError(t, err)
+
+	var commands bytes.Buffer
+	pdfProcess := NewPDFGopher(input,
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+		WithRotatePages(90, "1"),
+		WithDryRun(&commands),
+		WithoutBase64(),
+	)
+	assert.NoError(t, pdfProcess.ProcessFile())
+
+	// Baking the rotation is planned like every other change
+	assert.Regexp(t, `(?m)^pdfcpu rotate --pages 1 -- \S+\.pdf 90$`, commands.String())
+	assert.Regexp(t, `(?m)^pdfcpu boxes add --pages 1,2 -- 'media:\[0 0 595\.28 841\.89\]' \S+\.pdf$`, commands.String())
+	assert.Regexp(t, `(?m)^pdfcpu resize --pages 2 -- 'dim:841\.89 595\.28' \S+\.pdf$`, commands.String())
+
+	for _, filePath := range []string{input, pdfProcess.OutputPath} {
+		data, err := os.ReadFile(filePath)
+		assert.NoError(t, err)
+		assert.Equal(t, original, data)
+	}
+}
@@ -0,0 +1,248 @@
+package pdfgopher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrNoSignatureField is returned when a PDF file has no AcroForm signature field.
+var ErrNoSignatureField = errors.New("no signature field found")
+
+// Rect represents a rectangle in PDF user space, in points.
+type Rect struct {
+	LLX float64
+	LLY float64
+	URX float64
+	URY float64
+}
+
+var (
+	objectPattern    = regexp.MustCompile(`(?m)^\s*(\d+)\s+(\d+)\s+obj\b`)
+	streamPattern    = regexp.MustCompile(`>>\s*stream\b`)
+	referencePattern = regexp.MustCompile(`(\d+)\s+(\d+)\s+R\b`)
+	rootPattern      = regexp.MustCompile(`/Root\s*(\d+)\s+(\d+)\s+R\b`)
+	signaturePattern = regexp.MustCompile(`/FT\s*/Sig\b`)
+	numberPattern    = regexp.MustCompile(`-?\d*\.?\d+`)
+)
+
+// FindSignatureField locates the first AcroForm signature field of the PDF file,
+// so a stamp or signature can be placed exactly there.
+// The PDF is rewritten without object streams by pdfcpu-cli so its dictionaries can be read.
+func FindSignatureField(filePath string) (page int, rect Rect, err error) {
+	dir, err := os.MkdirTemp("", "signature-")
+	if err != nil {
+		return 0, Rect{}, err
+	}
+	defer os.RemoveAll(dir)
+
+	plainPath := filepath.Join(dir, "plain.pdf")
+	err = writeWithoutObjectStreams(filePath, plainPath, dir)
+	if err != nil {
+		return 0, Rect{}, err
+	}
+
+	data, err := os.ReadFile(plainPath)
+	if err != nil {
+		return 0, Rect{}, err
+	}
+
+	return findSignatureField(string(data))
+}
+
+// findSignatureField locates the first signature field in the data of a PDF file without
+// object streams. Incremental updates are honoured, the last revision of every object and
+// the catalog of the last trailer are used.
+func findSignatureField(data string) (int, Rect, error) {
+	objects := parseObjects(data)
+
+	roots := rootPattern.FindAllStringSubmatch(data, -1)
+	if roots == nil {
+		return 0, Rect{}, errors.New("PDF catalog not found")
+	}
+	root := roots[len(roots)-1]
+
+	pagesRef, ok := dictRef(objects[root[1]+" "+root[2]], "Pages")
+	if !ok {
+		return 0, Rect{}, errors.New("PDF page tree not found")
+	}
+
+	for i, pageRef := range collectPages(objects, pagesRef, 0) {
+		for _, annotRef := range pageAnnots(objects, objects[pageRef]) {
+			annot := objects[annotRef]
+			if !isSignatureField(objects, annot) {
+				continue
+			}
+
+			rect, err := dictRect(annot)
+			if err != nil {
+				return 0, Rect{}, err
+			}
+
+			return i + 1, rect, nil
+		}
+	}
+
+	return 0, Rect{}, ErrNoSignatureField
+}
+
+// writeWithoutObjectStreams writes a copy of the PDF file whose objects are stored uncompressed,
+// using a temporary pdfcpu configuration in configDir.
+func writeWithoutObjectStreams(filePath, output, configDir string) error {
+	// Let pdfcpu create its default configuration first
+	_, err := runPDFCPU(context.Background(), "--conf", configDir, "config", "list")
+	if err != nil {
+		return err
+	}
+
+	configPath := filepath.Join(configDir, "pdfcpu", "config.yml")
+	config, err := os.ReadFile(configPath)
+	if err != nil {
+		return err
+	}
+
+	replacer := strings.NewReplacer(
+		"writeObjectStream: true", "writeObjectStream: false",
+		"writeXRefStream: true", "writeXRefStream: false",
+	)
+	err = os.WriteFile(configPath, []byte(replacer.Replace(string(config))), 0644)
+	if err != nil {
+		return err
+	}
+
+	_, err = runPDFCPU(context.Background(), "--conf", configDir, "optimize", filePath, output)
+	return err
+}
+
+// parseObjects returns the dictionary of every indirect object keyed by its object and generation
+// number, e.g. "12 0". An object redefined by an incremental update keeps its last definition.
+// Stream data is dropped, only the dictionary in front of it is kept.
+func parseObjects(data string) map[string]string {
+	objects := make(map[string]string)
+
+	matches := objectPattern.FindAllStringSubmatchIndex(data, -1)
+	for i, match := range matches {
+		end := len(data)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+
+		body := data[match[1]:end]
+		if n := strings.Index(body, "endobj"); n >= 0 {
+			body = body[:n]
+		}
+		if loc := streamPattern.FindStringIndex(body); loc != nil {
+			body = body[:loc[0]+len(">>")]
+		}
+
+		objects[data[match[2]:match[3]]+" "+data[match[4]:match[5]]] = body
+	}
+
+	return objects
+}
+
+// collectPages returns the page object references below the page tree node in document order.
+func collectPages(objects map[string]string, node string, depth int) []string {
+	// Guard against cyclic page trees
+	if depth > 64 {
+		return nil
+	}
+
+	kids, ok := dictArray(objects[node], "Kids")
+	if !ok {
+		return []string{node}
+	}
+
+	var pages []string
+	for _, kid := range refs(kids) {
+		pages = append(pages, collectPages(objects, kid, depth+1)...)
+	}
+
+	return pages
+}
+
+// pageAnnots returns the annotation object references of a page dictionary.
+func pageAnnots(objects map[string]string, page string) []string {
+	if annots, ok := dictArray(page, "Annots"); ok {
+		return refs(annots)
+	}
+
+	// The annotations array may be an indirect object
+	if ref, ok := dictRef(page, "Annots"); ok {
+		return refs(objects[ref])
+	}
+
+	return nil
+}
+
+// isSignatureField reports whether the widget annotation, or the field it belongs to, is a signature field.
+func isSignatureField(objects map[string]string, annot string) bool {
+	if signaturePattern.MatchString(annot) {
+		return true
+	}
+
+	if parent, ok := dictRef(annot, "Parent"); ok {
+		return signaturePattern.MatchString(objects[parent])
+	}
+
+	return false
+}
+
+// dictRef returns the object and generation number of an indirect reference stored under key.
+func dictRef(dict string, key string) (string, bool) {
+	match := regexp.MustCompile(`/` + key + `\s*(\d+)\s+(\d+)\s+R\b`).FindStringSubmatch(dict)
+	if match == nil {
+		return "", false
+	}
+
+	return match[1] + " " + match[2], true
+}
+
+// dictArray returns the content of a direct array stored under key.
+func dictArray(dict string, key string) (string, bool) {
+	match := regexp.MustCompile(`/` + key + `\s*\[([^\]]*)\]`).FindStringSubmatch(dict)
+	if match == nil {
+		return "", false
+	}
+
+	return match[1], true
+}
+
+// dictRect returns the rectangle stored under the Rect key.
+func dictRect(dict string) (Rect, error) {
+	array, ok := dictArray(dict, "Rect")
+	if !ok {
+		return Rect{}, errors.New("signature field has no rectangle")
+	}
+
+	numbers := numberPattern.FindAllString(array, -1)
+	if len(numbers) != 4 {
+		return Rect{}, fmt.Errorf("invalid signature field rectangle: [%s]", array)
+	}
+
+	values := make([]float64, 4)
+	for i, number := range numbers {
+		value, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			return Rect{}, err
+		}
+		values[i] = value
+	}
+
+	return Rect{LLX: values[0], LLY: values[1], URX: values[2], URY: values[3]}, nil
+}
+
+// refs returns the object and generation numbers of all indirect references in s.
+func refs(s string) []string {
+	var references []string
+	for _, match := range referencePattern.FindAllStringSubmatch(s, -1) {
+		references = append(references, match[1]+" "+match[2])
+	}
+
+	return references
+}
@@ -0,0 +1,23 @@
+package pdfgopher_test
+
+import (
+	"testing"
+
+	. "github.com/RamdhaniMichan/PDFGopher"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindSignatureField(t *testing.T) {
+	page, rect, err := FindSignatureField("./sample_pdf/signature_field.pdf")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, page)
+	assert.Equal(t, Rect{LLX: 400, LLY: 50, URX: 550, URY: 100}, rect)
+}
+
+func TestFindSignatureFieldMissing(t *testing.T) {
+	_, _, err := FindSignatureField("./sample_pdf/process-tree-736885__480.pdf")
+
+	assert.ErrorIs(t, err, ErrNoSignatureField)
+}
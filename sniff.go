@@ -0,0 +1,97 @@
+package pdfgopher
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+)
+
+var (
+	// oleSignature starts every OLE compound file, such as DOC files.
+	oleSignature = []byte("\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1")
+
+	// zipSignature starts every ZIP archive, such as DOCX files.
+	zipSignature = []byte("PK\x03\x04")
+)
+
+// WithFileTypeCheck returns an Option function that rejects input files whose content doesn't
+// match their extension, such as a JPEG named scan.pdf.
+func WithFileTypeCheck() Option {
+	return func(p *PDFProcessor) {
+		p.checkFileType = true
+	}
+}
+
+// sniffFileType detects the file type and its usual extension from the magic bytes of data.
+// Documents are only recognized by their OLE or OOXML signatures.
+func sniffFileType(data []byte) (FileType, string) {
+	switch {
+	case bytes.HasPrefix(data, []byte("%PDF-")):
+		return PDF, ".pdf"
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return Image, ".png"
+	case bytes.HasPrefix(data, []byte("\xff\xd8\xff")):
+		return Image, ".jpg"
+	case len(data) >= 12 && bytes.HasPrefix(data, []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WEBP")):
+		return Image, ".webp"
+	case bytes.HasPrefix(data, []byte("II*\x00")) || bytes.HasPrefix(data, []byte("MM\x00*")):
+		return Image, ".tif"
+	case bytes.HasPrefix(data, oleSignature):
+		return Document, ".doc"
+	case bytes.HasPrefix(data, zipSignature):
+		archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err == nil && isOOXML(archive) {
+			return Document, ".docx"
+		}
+		return "", ""
+	default:
+		return "", ""
+	}
+}
+
+// sniffFile detects the type of the file at filePath from its magic bytes, without reading
+// more of it than the signatures need.
+func sniffFile(filePath string) FileType {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	header := make([]byte, 12)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return ""
+	}
+	header = header[:n]
+
+	// The OOXML content types are listed in the central directory at the end of the archive
+	if bytes.HasPrefix(header, zipSignature) {
+		info, err := file.Stat()
+		if err != nil {
+			return ""
+		}
+
+		archive, err := zip.NewReader(file, info.Size())
+		if err != nil || !isOOXML(archive) {
+			return ""
+		}
+		return Document
+	}
+
+	fileType, _ := sniffFileType(header)
+	return fileType
+}
+
+// isOOXML reports whether the ZIP archive is an Office Open XML package, which lists its
+// parts in [Content_Types].xml.
+func isOOXML(archive *zip.Reader) bool {
+	for _, file := range archive.File {
+		if file.Name == "[Content_Types].xml" {
+			return true
+		}
+	}
+
+	return false
+}
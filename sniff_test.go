@@ -0,0 +1,191 @@
+package pdfgopher_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/RamdhaniMichan/PDFGopher"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/tiff"
+)
+
+func TestDetectFileTypeByContent(t *testing.T) {
+	dir := t.TempDir()
+
+	copyInto(t, "./sample_pdf/process-tree-736885__480.pdf", filepath.Join(dir, "upload"))
+	assert.Equal(t, PDF, DetectFileType(filepath.Join(dir, "upload")))
+
+	copyInto(t, "./sample_image/tree-736885__480.jpg", filepath.Join(dir, "scan.bin"))
+	assert.Equal(t, Image, DetectFileType(filepath.Join(dir, "scan.bin")))
+
+	copyInto(t, "./sample_image/qr-generate.png", filepath.Join(dir, "qr"))
+	assert.Equal(t, Image, DetectFileType(filepath.Join(dir, "qr")))
+
+	copyInto(t, "./sample_image/blue-purple-pink.webp", filepath.Join(dir, "photo"))
+	assert.Equal(t, Image, DetectFileType(filepath.Join(dir, "photo")))
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "fax"), []byte("II*\x00\x08\x00\x00\x00"), 0644))
+	assert.Equal(t, Image, DetectFileType(filepath.Join(dir, "fax")))
+
+	ole := append([]byte("\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1"), make([]byte, 504)...)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "letter"), ole, 0644))
+	assert.Equal(t, Document, DetectFileType(filepath.Join(dir, "letter")))
+
+	writeZip(t, filepath.Join(dir, "report"), "[Content_Types].xml", "word/document.xml")
+	assert.Equal(t, Document, DetectFileType(filepath.Join(dir, "report")))
+
+	// Only OOXML packages are documents, not any ZIP archive
+	writeZip(t, filepath.Join(dir, "archive"), "readme.txt")
+	assert.Equal(t, FileType(""), DetectFileType(filepath.Join(dir, "archive")))
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "notes"), []byte("plain text"), 0644))
+	assert.Equal(t, FileType(""), DetectFileType(filepath.Join(dir, "notes")))
+}
+
+func TestProcessFileWithoutExtension(t *testing.T) {
+	imagePath := filepath.Join(t.TempDir(), "upload")
+	copyInto(t, "./sample_image/tree-736885__480.jpg", imagePath)
+
+	pdfProcess := NewPDFGopher(imagePath, WithoutBase64(), WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}))
+	defer pdfProcess.Close()
+
+	assert.NoError(t, pdfProcess.ProcessFile())
+	assert.Equal(t, PDF, DetectFileType(pdfProcess.OutputPath))
+}
+
+func TestProcessFileWithFileTypeCheck(t *testing.T) {
+	// A JPEG named as a PDF
+	imagePath := filepath.Join(t.TempDir(), "scan.pdf")
+	copyInto(t, "./sample_image/tree-736885__480.jpg", imagePath)
+
+	err := NewPDFGopher(imagePath, WithFileTypeCheck()).ProcessFile()
+	assert.EqualError(t, err, "file content doesn't match its extension: image content in scan.pdf")
+
+	pdfPath := filepath.Join(t.TempDir(), "document.pdf")
+	copyInto(t, "./sample_pdf/process-tree-736885__480.pdf", pdfPath)
+
+	pdfProcess := NewPDFGopher(pdfPath, WithoutBase64(), WithFileTypeCheck(), WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}))
+	defer pdfProcess.Close()
+
+	assert.NoError(t, pdfProcess.ProcessFile())
+}
+
+// writeZip writes a ZIP archive with empty entries of the given names to path.
+func writeZip(t *testing.T, path string, names ...string) {
+	t.Helper()
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+	for _, name := range names {
+		if _, err := archive.Create(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProcessFileWebPAndTIFF(t *testing.T) {
+	dir := t.TempDir()
+
+	webpPath := filepath.Join(dir, "photo.webp")
+	copyInto(t, "./sample_image/blue-purple-pink.webp", webpPath)
+
+	// A 16-bit grayscale scan, which gofpdf can't embed as it is
+	tiffPath := filepath.Join(dir, "scan.tiff")
+	scan := image.NewGray16(image.Rect(0, 0, 40, 60))
+	for i := range scan.Pix {
+		scan.Pix[i] = byte(i)
+	}
+	file, err := os.Create(tiffPath)
+	assert.NoError(t, err)
+	assert.NoError(t, tiff.Encode(file, scan, nil))
+	assert.NoError(t, file.Close())
+
+	for _, imagePath := range []string{webpPath, tiffPath} {
+		pdfProcess := NewPDFGopher(imagePath, WithoutBase64(), WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}))
+		defer pdfProcess.Close()
+
+		assert.Equal(t, Image, DetectFileType(imagePath))
+		if assert.NoError(t, pdfProcess.ProcessFile(), imagePath) {
+			assert.Equal(t, 1, readInfo(t, pdfProcess.OutputPath).PageCount)
+		}
+	}
+}
+
+func TestProcessFileMultiPageTIFF(t *testing.T) {
+	tiffPath := filepath.Join(t.TempDir(), "scan.tif")
+	writeMultiPageTIFF(t, tiffPath, image.Pt(40, 60), image.Pt(60, 40), image.Pt(30, 30))
+
+	pdfProcess := NewPDFGopher(tiffPath,
+		WithoutBase64(),
+		WithPageSize("A4", "auto"),
+		WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}),
+	)
+	defer pdfProcess.Close()
+
+	assert.NoError(t, pdfProcess.ProcessFile())
+
+	// Every page follows the orientation of the first frame
+	info := readInfo(t, pdfProcess.OutputPath, "--pages", "1-")
+	assert.Equal(t, 3, info.PageCount)
+	if assert.Len(t, info.PageBoundaries, 3) {
+		for _, page := range info.PageBoundaries {
+			assert.InDelta(t, 595.28, page.MediaBox.Rect.UR.X, 0.01)
+		}
+	}
+
+	pages := pageStreams(t, pdfProcess.OutputPath)
+	if assert.Len(t, pages, 3) {
+		for _, page := range pages {
+			assert.Contains(t, page, "Do")
+		}
+	}
+}
+
+// writeMultiPageTIFF writes an uncompressed 8-bit grayscale TIFF file with a page of every size.
+func writeMultiPageTIFF(t *testing.T, path string, sizes ...image.Point) {
+	var buf bytes.Buffer
+	buf.WriteString("II*\x00")
+	binary.Write(&buf, binary.LittleEndian, uint32(0))
+
+	previous := 4
+	for _, size := range sizes {
+		pixelsOffset := buf.Len()
+		buf.Write(bytes.Repeat([]byte{0x80}, size.X*size.Y))
+
+		// Link the previous directory, or the header, to this one
+		ifdOffset := buf.Len()
+		binary.LittleEndian.PutUint32(buf.Bytes()[previous:], uint32(ifdOffset))
+
+		entries := [][2]uint32{
+			{256, uint32(size.X)}, {257, uint32(size.Y)}, {258, 8}, {259, 1}, {262, 1},
+			{273, uint32(pixelsOffset)}, {277, 1}, {278, uint32(size.Y)}, {279, uint32(size.X * size.Y)},
+		}
+		binary.Write(&buf, binary.LittleEndian, uint16(len(entries)))
+		for _, entry := range entries {
+			binary.Write(&buf, binary.LittleEndian, uint16(entry[0]))
+			binary.Write(&buf, binary.LittleEndian, uint16(4)) // LONG
+			binary.Write(&buf, binary.LittleEndian, uint32(1))
+			binary.Write(&buf, binary.LittleEndian, entry[1])
+		}
+
+		previous = buf.Len()
+		binary.Write(&buf, binary.LittleEndian, uint32(0))
+	}
+
+	assert.NoError(t, os.WriteFile(path, buf.Bytes(), 0644))
+}
@@ -0,0 +1,76 @@
+package pdfgopher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SplitPDF extracts the pages of every range, such as "1-3" or "5", from the input PDF into
+// its own file in outputDir, named after the input with a "-part-N" suffix in range order.
+// Ranges use the page selectors of StampPages without exclusions and must lie within the document.
+// It returns the paths of the produced files.
+func SplitPDF(input string, ranges []string, outputDir string) ([]string, error) {
+	if len(ranges) == 0 {
+		return nil, errors.New("no page ranges to split")
+	}
+
+	ctx := context.Background()
+	count, err := pageCount(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	selections := make([]string, len(ranges))
+	for i, selector := range ranges {
+		pages, err := splitRangePages(selector, count)
+		if err != nil {
+			return nil, err
+		}
+
+		selections[i] = joinPages(pages)
+	}
+
+	err = os.MkdirAll(outputDir, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSuffix(filepath.Base(input), filepath.Ext(input))
+	outputs := make([]string, len(ranges))
+	for i, selection := range selections {
+		outputs[i] = filepath.Join(outputDir, fmt.Sprintf("%s-part-%d.pdf", name, i+1))
+
+		_, err := runPDFCPU(ctx, "trim", "--pages", selection, input, outputs[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return outputs, nil
+}
+
+// splitRangePages resolves a page range of SplitPDF against a document of count pages.
+// Unlike stamp selections, pages beyond the document are an error rather than ignored.
+func splitRangePages(selector string, count int) ([]int, error) {
+	for _, term := range strings.Split(selector, ",") {
+		term = strings.TrimSpace(term)
+		if strings.HasPrefix(term, "!") || strings.HasPrefix(term, "n") {
+			return nil, fmt.Errorf("invalid page range %q: exclusions are not supported", selector)
+		}
+
+		first, last, err := pageRange(term, count)
+		if err != nil {
+			return nil, fmt.Errorf("invalid page range %q: %s", selector, err.Error())
+		}
+
+		if first < 1 || last > count || first > last {
+			return nil, fmt.Errorf("page range %q out of range, the document has %d pages", selector, count)
+		}
+	}
+
+	return parsePageSelection(selector, count)
+}
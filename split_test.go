@@ -0,0 +1,49 @@
+package pdfgopher_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	. "github.com/RamdhaniMichan/PDFGopher"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitPDF(t *testing.T) {
+	input := multiPagePDF(t, 5)
+	outputDir := filepath.Join(t.TempDir(), "parts")
+
+	outputs, err := SplitPDF(input, []string{"1-3", "5"}, outputDir)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(outputDir, "pages-part-1.pdf"), filepath.Join(outputDir, "pages-part-2.pdf")}, outputs)
+
+	if assert.Len(t, outputs, 2) {
+		assert.Equal(t, 3, readInfo(t, outputs[0]).PageCount)
+
+		pages := pageStreams(t, outputs[1])
+		if assert.Len(t, pages, 1) {
+			assert.Contains(t, pages[0], "(Page 5)")
+		}
+	}
+}
+
+func TestSplitPDFInvalidRanges(t *testing.T) {
+	input := multiPagePDF(t, 5)
+	outputDir := t.TempDir()
+
+	_, err := SplitPDF(input, []string{"1", "4-7"}, outputDir)
+	assert.EqualError(t, err, `page range "4-7" out of range, the document has 5 pages`)
+
+	_, err = SplitPDF(input, []string{"6"}, outputDir)
+	assert.EqualError(t, err, `page range "6" out of range, the document has 5 pages`)
+
+	_, err = SplitPDF(input, []string{"two"}, outputDir)
+	assert.EqualError(t, err, `invalid page range "two": invalid page two`)
+
+	_, err = SplitPDF(input, nil, outputDir)
+	assert.EqualError(t, err, "no page ranges to split")
+
+	// Nothing is written when a range is invalid
+	assert.NoFileExists(t, filepath.Join(outputDir, "pages-part-1.pdf"))
+}
@@ -0,0 +1,58 @@
+package pdfgopher
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ProcessStdio reads a PDF, image or document file from os.Stdin, processes it with the given options
+// and writes the resulting PDF to os.Stdout. The file type is detected from its magic bytes.
+func ProcessStdio(options ...Option) error {
+	return processStream(os.Stdin, os.Stdout, options...)
+}
+
+// processStream processes the file read from r and writes the resulting PDF to w.
+func processStream(r io.Reader, w io.Writer, options ...Option) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	fileType, extension := sniffFileType(data)
+	if fileType == "" {
+		return ErrUnsupportedFileType
+	}
+
+	// The result is streamed from the output file, base64 would be wasted work.
+	// The input is a private temporary file, so it can be processed in place.
+	processor := NewPDFGopher("", append(options, WithoutBase64(), WithWritePolicy(InPlace))...)
+	defer processor.Close()
+
+	dir, err := os.MkdirTemp(processor.tempDir, "stdio-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	filePath := filepath.Join(dir, "stdin"+extension)
+	err = os.WriteFile(filePath, data, 0644)
+	if err != nil {
+		return err
+	}
+
+	processor.FilePath = filePath
+	err = processor.ProcessFile()
+	if err != nil {
+		return err
+	}
+
+	output, err := os.Open(processor.OutputPath)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	_, err = io.Copy(w, output)
+	return err
+}
@@ -0,0 +1,60 @@
+package pdfgopher_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	. "github.com/RamdhaniMichan/PDFGopher"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessStdio(t *testing.T) {
+	input, err := os.ReadFile("./sample_pdf/process-tree-736885__480.pdf")
+	assert.NoError(t, err)
+
+	stdinReader, stdinWriter, err := os.Pipe()
+	assert.NoError(t, err)
+	stdoutReader, stdoutWriter, err := os.Pipe()
+	assert.NoError(t, err)
+
+	stdin, stdout := os.Stdin, os.Stdout
+	os.Stdin, os.Stdout = stdinReader, stdoutWriter
+	defer func() { os.Stdin, os.Stdout = stdin, stdout }()
+
+	go func() {
+		stdinWriter.Write(input)
+		stdinWriter.Close()
+	}()
+
+	output := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(stdoutReader)
+		output <- data
+	}()
+
+	err = ProcessStdio(WithOptionFilePDF(OptionFilePDF{QRCodePath: "./sample_image/qr-generate.png"}))
+	stdoutWriter.Close()
+
+	assert.NoError(t, err)
+
+	result := <-output
+	assert.True(t, bytes.HasPrefix(result, []byte("%PDF-")))
+	assert.NotEqual(t, input, result)
+}
+
+func TestProcessStdioUnsupported(t *testing.T) {
+	stdinReader, stdinWriter, err := os.Pipe()
+	assert.NoError(t, err)
+
+	stdin := os.Stdin
+	os.Stdin = stdinReader
+	defer func() { os.Stdin = stdin }()
+
+	stdinWriter.Write([]byte("plain text"))
+	stdinWriter.Close()
+
+	assert.Error(t, ProcessStdio())
+}
@@ -0,0 +1,105 @@
+package pdfgopher
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ExtractText extracts the text of a single page of the PDF file using pdftotext.
+func ExtractText(filePath string, page int) (string, error) {
+	return extractText(context.Background(), filePath, page)
+}
+
+// extractText extracts the text of a single page of the PDF file like ExtractText,
+// pdftotext is killed when ctx is done.
+func extractText(ctx context.Context, filePath string, page int) (string, error) {
+	if page < 1 {
+		return "", fmt.Errorf("invalid page number: %d", page)
+	}
+
+	pageNumber := strconv.Itoa(page)
+
+	// Execute the command
+	cmd := exec.CommandContext(ctx, executable("pdftotext"), "-layout", "-f", pageNumber, "-l", pageNumber, filePath, "-")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("error executing pdftotext command: %s", err.Error())
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// PDFToTextFile extracts the text of every page of the PDF file into outputTxt.
+// Pages are separated by a "--- Page N ---" line. Pages without a text layer, such as scans,
+// are passed through OCR when tesseract is installed.
+func PDFToTextFile(pdfPath, outputTxt string) error {
+	text, err := documentText(context.Background(), pdfPath)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(outputTxt, []byte(text), 0644)
+}
+
+// documentText returns the text of every page of the PDF file in the format of PDFToTextFile.
+func documentText(ctx context.Context, pdfPath string) (string, error) {
+	count, err := pageCount(ctx, pdfPath)
+	if err != nil {
+		return "", err
+	}
+
+	_, lookErr := exec.LookPath(executable("tesseract"))
+	ocrAvailable := lookErr == nil
+
+	var builder strings.Builder
+	for page := 1; page <= count; page++ {
+		text, err := extractText(ctx, pdfPath, page)
+		if err != nil {
+			return "", err
+		}
+
+		if text == "" && ocrAvailable {
+			text, err = ocrPage(ctx, pdfPath, page)
+			if err != nil {
+				return "", err
+			}
+		}
+
+		fmt.Fprintf(&builder, "--- Page %d ---\n%s\n", page, text)
+	}
+
+	return builder.String(), nil
+}
+
+// ocrPage renders a single page with pdftoppm and recognizes its text with tesseract.
+func ocrPage(ctx context.Context, filePath string, page int) (string, error) {
+	dir, err := os.MkdirTemp("", "ocr-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	imagePrefix := filepath.Join(dir, "page")
+	pageNumber := strconv.Itoa(page)
+
+	// Execute the command
+	cmd := exec.CommandContext(ctx, executable("pdftoppm"), "-png", "-r", "300", "-singlefile", "-f", pageNumber, "-l", pageNumber, filePath, imagePrefix)
+	err = cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("error executing pdftoppm command: %s", err.Error())
+	}
+
+	// Execute the command
+	cmd = exec.CommandContext(ctx, executable("tesseract"), imagePrefix+".png", "-")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("error executing tesseract command: %s", err.Error())
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,38 @@
+package pdfgopher_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	. "github.com/RamdhaniMichan/PDFGopher"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPDFToTextFile(t *testing.T) {
+	if _, err := exec.LookPath("pdftotext"); err != nil {
+		t.Skip("pdftotext is not installed")
+	}
+
+	dir := t.TempDir()
+	pdfPath := filepath.Join(dir, "text.pdf")
+	outputTxt := filepath.Join(dir, "text.txt")
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Helvetica", "", 12)
+	pdf.AddPage()
+	pdf.Cell(0, 10, "Hello Gopher")
+	pdf.AddPage()
+	pdf.Cell(0, 10, "Second page")
+	assert.NoError(t, pdf.OutputFileAndClose(pdfPath))
+
+	err := PDFToTextFile(pdfPath, outputTxt)
+	assert.NoError(t, err)
+
+	content, err := os.ReadFile(outputTxt)
+	assert.NoError(t, err)
+	assert.Equal(t, "--- Page 1 ---\nHello Gopher\n--- Page 2 ---\nSecond page\n", string(content))
+}
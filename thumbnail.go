@@ -0,0 +1,97 @@
+package pdfgopher
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+// defaultThumbnailWidth is the width in pixels of thumbnails generated without WithThumbnailWidth.
+const defaultThumbnailWidth = 200
+
+// ThumbnailOption is a function type used for applying options to thumbnail generation.
+type ThumbnailOption func(*thumbnailConfig)
+
+// thumbnailConfig holds the options applied when generating a thumbnail.
+type thumbnailConfig struct {
+	width int
+}
+
+// WithThumbnailWidth returns a ThumbnailOption function that sets the width of the thumbnail
+// in pixels, 200 by default. The height follows the aspect ratio of the page.
+func WithThumbnailWidth(width int) ThumbnailOption {
+	return func(c *thumbnailConfig) {
+		c.width = width
+	}
+}
+
+// GenerateThumbnail renders a single page of the PDF file, the first one when page is zero,
+// to a PNG thumbnail at outPath using pdftoppm and returns outPath.
+func GenerateThumbnail(pdfPath string, page int, outPath string, options ...ThumbnailOption) (string, error) {
+	config := thumbnailConfig{width: defaultThumbnailWidth}
+	for _, opt := range options {
+		opt(&config)
+	}
+
+	if page == 0 {
+		page = 1
+	}
+
+	thumbnail, err := renderThumbnail(pdfPath, page, config.width)
+	if err != nil {
+		return "", err
+	}
+
+	err = os.WriteFile(outPath, thumbnail, 0644)
+	if err != nil {
+		return "", err
+	}
+
+	return outPath, nil
+}
+
+// ThumbnailDataURI renders a single page of the PDF file to a PNG thumbnail of the given width
+// using pdftoppm and returns it as a data:image/png;base64 URI, ready to embed in a web page.
+// The height follows the aspect ratio of the page and no file is written.
+func ThumbnailDataURI(pdfPath string, page, width int) (string, error) {
+	thumbnail, err := renderThumbnail(pdfPath, page, width)
+	if err != nil {
+		return "", err
+	}
+
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(thumbnail), nil
+}
+
+// renderThumbnail renders a single page of the PDF file to a PNG image of the given width
+// using pdftoppm and returns the encoded image.
+func renderThumbnail(pdfPath string, page, width int) ([]byte, error) {
+	if page < 1 {
+		return nil, fmt.Errorf("invalid page number: %d", page)
+	}
+
+	if width <= 0 {
+		return nil, fmt.Errorf("invalid thumbnail width: %d", width)
+	}
+
+	_, err := exec.LookPath(executable("pdftoppm"))
+	if err != nil {
+		return nil, fmt.Errorf("poppler-utils is required to render thumbnails, pdftoppm not found: %s", err.Error())
+	}
+
+	pageNumber := strconv.Itoa(page)
+
+	// Execute the command, pdftoppm writes the image to stdout without an output root
+	cmd := exec.Command(executable("pdftoppm"), "-png", "-singlefile", "-f", pageNumber, "-l", pageNumber, "-scale-to-x", strconv.Itoa(width), "-scale-to-y", "-1", pdfPath)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("error executing pdftoppm command: %s", err.Error())
+	}
+
+	if len(output) == 0 {
+		return nil, fmt.Errorf("page %d not found in %s", page, pdfPath)
+	}
+
+	return output, nil
+}
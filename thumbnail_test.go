@@ -0,0 +1,75 @@
+package pdfgopher_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/RamdhaniMichan/PDFGopher"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThumbnailDataURI(t *testing.T) {
+	if _, err := exec.LookPath("pdftoppm"); err != nil {
+		t.Skip("pdftoppm is not installed")
+	}
+
+	uri, err := ThumbnailDataURI(multiPagePDF(t, 2), 2, 120)
+	assert.NoError(t, err)
+
+	payload, ok := strings.CutPrefix(uri, "data:image/png;base64,")
+	if assert.True(t, ok) {
+		data, err := base64.StdEncoding.DecodeString(payload)
+		assert.NoError(t, err)
+
+		img, err := png.Decode(bytes.NewReader(data))
+		if assert.NoError(t, err) {
+			assert.Equal(t, 120, img.Bounds().Dx())
+		}
+	}
+}
+
+func TestThumbnailDataURIInvalidArguments(t *testing.T) {
+	_, err := ThumbnailDataURI("./sample_pdf/process-tree-736885__480.pdf", 0, 120)
+	assert.EqualError(t, err, "invalid page number: 0")
+
+	_, err = ThumbnailDataURI("./sample_pdf/process-tree-736885__480.pdf", 1, 0)
+	assert.EqualError(t, err, "invalid thumbnail width: 0")
+}
+
+func TestGenerateThumbnail(t *testing.T) {
+	if _, err := exec.LookPath("pdftoppm"); err != nil {
+		t.Skip("pdftoppm is not installed")
+	}
+
+	outPath := filepath.Join(t.TempDir(), "thumbnail.png")
+
+	path, err := GenerateThumbnail(multiPagePDF(t, 2), 0, outPath, WithThumbnailWidth(90))
+	assert.NoError(t, err)
+	assert.Equal(t, outPath, path)
+
+	file, err := os.Open(outPath)
+	if assert.NoError(t, err) {
+		defer file.Close()
+
+		config, err := png.DecodeConfig(file)
+		assert.NoError(t, err)
+		assert.Equal(t, 90, config.Width)
+	}
+}
+
+func TestGenerateThumbnailWithoutPdftoppm(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	_, err := GenerateThumbnail("./sample_pdf/process-tree-736885__480.pdf", 1, filepath.Join(t.TempDir(), "thumbnail.png"))
+	assert.ErrorContains(t, err, "pdftoppm not found")
+
+	_, err = GenerateThumbnail("./sample_pdf/process-tree-736885__480.pdf", -1, "thumbnail.png")
+	assert.EqualError(t, err, "invalid page number: -1")
+}